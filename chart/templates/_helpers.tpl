@@ -67,7 +67,9 @@ Generate image args
 {{- if .Values.includeLocalNamespaceOnly }}
 {{- $args = append $args (printf "--include-namespaces=%s" .Release.Namespace) }}
 {{- end }}
-{{- if .Values.sealedSecrets.certURL }}
+{{- if .Values.sealedSecrets.certFile }}
+  {{- $args = append $args (printf "--sealed-secrets-cert-file=%s" .Values.sealedSecrets.certFile ) }}
+{{- else if .Values.sealedSecrets.certURL }}
   {{- $args = append $args (printf "--sealed-secrets-cert-url=%s" .Values.sealedSecrets.certURL ) }}
 {{- else }}
   {{- if .Values.sealedSecrets.namespace }}
@@ -77,6 +79,15 @@ Generate image args
   {{- $args = append $args (printf "--sealed-secrets-service-name=%s" .Values.sealedSecrets.serviceName) }}
   {{- end }}
 {{- end }}
+{{- if .Values.sealedSecrets.verifyURL }}
+  {{- $args = append $args (printf "--sealed-secrets-verify-url=%s" .Values.sealedSecrets.verifyURL ) }}
+{{- end }}
+{{- if .Values.sealedSecrets.readControllerKeys }}
+  {{- $args = append $args "--sealed-secrets-read-controller-keys" }}
+{{- end }}
+{{- if .Values.sealedSecrets.certRefreshInterval }}
+  {{- $args = append $args (printf "--sealed-secrets-cert-refresh-interval=%s" .Values.sealedSecrets.certRefreshInterval ) }}
+{{- end }}
 {{- if .Values.webContext }}
 {{- $args = append $args (printf "--web-context=%s" .Values.webContext) }}
 {{- end }}
@@ -86,6 +97,15 @@ Generate image args
 {{- if .Values.disableLoadSecrets  }}
 {{- $args = append $args "--disable-load-secrets" }}
 {{- end }}
+{{- if .Values.enableApply  }}
+{{- $args = append $args "--enable-apply" }}
+{{- end }}
+{{- if .Values.auditLog  }}
+{{- $args = append $args "--enable-audit-log" }}
+{{- end }}
+{{- if .Values.impersonateUsers  }}
+{{- $args = append $args "--impersonate-users" }}
+{{- end }}
 {{- if .Values.showOnlySyncedSecrets  }}
 {{- $args = append $args "--show-only-synced-secrets" }}
 {{- end }}
@@ -16,14 +16,21 @@ import (
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/clientcmd"
 
+	"github.com/bakito/sealed-secrets-web/pkg/audit"
+	"github.com/bakito/sealed-secrets-web/pkg/auth"
 	"github.com/bakito/sealed-secrets-web/pkg/config"
 	"github.com/bakito/sealed-secrets-web/pkg/handler"
+	"github.com/bakito/sealed-secrets-web/pkg/metrics"
+	"github.com/bakito/sealed-secrets-web/pkg/policy"
 	"github.com/bakito/sealed-secrets-web/pkg/seal"
 	"github.com/bakito/sealed-secrets-web/pkg/version"
 
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 )
 
+// syncStatesInterval is the interval to count the SealedSecrets by synced state, if they are not cached.
+const syncStatesInterval = time.Minute
+
 var (
 	//go:embed templates/index.html
 	indexTemplate string
@@ -58,59 +65,157 @@ func main() {
 		return
 	}
 
-	coreClient, ssc, err := handler.BuildClients(clientConfig, cfg.DisableLoadSecrets)
+	coreClient, ssc, err := handler.BuildClients(clientConfig, cfg.DisableLoadSecrets && !cfg.EnableApply, nil)
 	if err != nil {
 		log.Fatalf("Could build k8s clients:%v", err.Error())
 	}
-	sealer, err := seal.NewAPISealer(cfg.Ctx, cfg.SealedSecrets)
+	secretCache := startSecretCache(cfg, ssc)
+	if secretCache == nil {
+		// without cache, the SealedSecrets are counted by synced state with a periodic listing
+		go handler.NewHandler(coreClient, ssc, cfg).ReportSyncStates(cfg.Ctx, syncStatesInterval)
+	}
+	registry, err := seal.NewAPIRegistry(cfg.Ctx, cfg.SealingTargets())
 	if err != nil {
 		log.Fatalf("Setup sealer: %s", err.Error())
 	}
 
+	if cfg.Web.MetricsPort != 0 {
+		go serveMetrics(cfg.Web.MetricsPort)
+	}
+
 	log.Printf("Running sealed secrets web (%s) on port %d", version.Version, cfg.Web.Port)
-	_ = setupRouter(coreClient, ssc, cfg, sealer).Run(fmt.Sprintf(":%d", cfg.Web.Port))
+	_ = setupRouter(coreClient, ssc, cfg, registry, secretCache).Run(fmt.Sprintf(":%d", cfg.Web.Port))
 }
 
 func setupRouter(
 	coreClient corev1.CoreV1Interface,
 	ssClient ssclient.BitnamiV1alpha1Interface,
 	cfg *config.Config,
-	sealer seal.Sealer,
+	registry *seal.Registry,
+	secretCache *handler.SecretCache,
 ) *gin.Engine {
 	indexHTML, err := renderIndexHTML(cfg)
 	if err != nil {
 		log.Fatalf("Could not render the index html template: %s", err.Error())
 	}
 
+	auditor, err := audit.New(cfg.Audit)
+	if err != nil {
+		log.Fatalf("Could not setup the audit log: %s", err.Error())
+	}
+
+	sealingPolicy, err := policy.Load(cfg.PolicyFile)
+	if err != nil {
+		log.Fatalf("Could not load the sealing policy: %s", err.Error())
+	}
+
+	if cfg.Unseal.Enabled {
+		log.Println("Unsealing of sealed secrets (break-glass mode) is enabled")
+	}
+
 	sHandler := handler.NewHandler(coreClient, ssClient, cfg)
+	sHandler.EnableAudit(auditor)
+	if secretCache != nil {
+		sHandler.UseCache(secretCache)
+	}
+	if sealer := registry.Default(); sealer != nil {
+		sHandler.UseSealer(sealer)
+	}
+	if !cfg.DisableLoadSecrets {
+		sHandler.UseMetadataClients(handler.MetadataClients(clientConfig))
+	}
+	if cfg.Impersonation.Enabled && (!cfg.DisableLoadSecrets || cfg.EnableApply) {
+		sHandler.Impersonate(handler.ImpersonatingClients(clientConfig))
+	}
 
 	r := gin.New()
 	r.Use(gin.Recovery())
+	r.Use(metrics.Middleware())
 	if cfg.Web.Logger {
 		r.Use(gin.LoggerWithFormatter(ginLogFormatter()))
 	}
-	h := handler.New(indexHTML, sealer, cfg)
+	if cfg.OIDC.Enabled() {
+		authenticator, err := auth.NewOIDC(cfg.Ctx, cfg.OIDC, cfg.Web.Context)
+		if err != nil {
+			log.Fatalf("Could not setup the OIDC login: %s", err.Error())
+		}
+		r.Use(authenticator.Middleware())
+		authenticator.Register(r)
+	}
+	h := handler.New(indexHTML, registry, cfg)
+	h.EnableAudit(auditor)
+	h.EnablePolicy(sealingPolicy)
 
 	r.GET("/", h.Index)
 	r.StaticFS("/static", http.FS(staticFS))
 	r.GET("/_health", h.Health)
+	if cfg.Web.MetricsPort == 0 {
+		r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
 
 	api := r.Group("/api")
 
 	api.GET("/version", h.Version)
 	api.POST("/raw", h.Raw)
 	api.GET("/certificate", h.Certificate)
+	api.GET("/targets", h.Targets)
 	api.POST("/kubeseal", h.KubeSeal)
+	api.POST("/kubeseal/merge", h.Merge)
 	api.POST("/dencode", h.Dencode)
+	api.POST("/generate", h.Generate)
+	api.POST("/import", h.Import)
 	api.POST("/validate", h.Validate)
+	api.POST("/policy/check", h.CheckPolicy)
+	api.POST("/unseal", h.Unseal)
+	api.POST("/reencrypt", h.ReEncrypt)
+	api.POST("/inspect", h.Inspect)
 
 	api.GET("/secret/:namespace/:name", sHandler.Secret)
 	api.GET("/secrets", sHandler.AllSecrets)
+	api.GET("/secrets/events", sHandler.Events)
+	api.GET("/secrets/unmanaged", sHandler.UnmanagedSecrets)
+	api.GET("/secrets/outdated", sHandler.OutdatedSecrets)
+	api.GET("/drift/:namespace/:name", sHandler.Drift)
+	api.POST("/apply", sHandler.Apply)
 
 	r.NoRoute(h.RedirectToIndex(cfg.Web.Context))
 	return r
 }
 
+// startSecretCache starts the informer cache of the SealedSecrets. Without a cache, the secrets are listed
+// from the api server on each request, which is also the case with impersonation.
+func startSecretCache(cfg *config.Config, ssc ssclient.BitnamiV1alpha1Interface) *handler.SecretCache {
+	if cfg.DisableLoadSecrets || cfg.Impersonation.Enabled || ssc == nil {
+		return nil
+	}
+
+	var namespaces []string
+	if !cfg.UseRegex {
+		namespaces = cfg.IncludeNamespaces
+	}
+
+	secretCache := handler.NewSecretCache(ssc, namespaces...)
+	if err := secretCache.Start(cfg.Ctx, time.Minute); err != nil {
+		log.Printf("Listing secrets without cache: %v", err)
+		return nil
+	}
+	return secretCache
+}
+
+func serveMetrics(port int) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("Serving metrics on port %d", port)
+	if err := server.ListenAndServe(); err != nil {
+		log.Fatalf("Could not serve the metrics: %s", err.Error())
+	}
+}
+
 func renderIndexHTML(cfg *config.Config) (string, error) {
 	indexTmpl := template.Must(template.New("index.html").Parse(indexTemplate))
 	initialSecret := initialSecretYAML
@@ -119,11 +224,12 @@ func renderIndexHTML(cfg *config.Config) (string, error) {
 	}
 
 	data := map[string]any{
-		"DisableLoadSecrets":     cfg.DisableLoadSecrets,
-		"DisableValidateSecrets": cfg.SealedSecrets.CertURL != "",
-		"WebContext":             cfg.Web.Context,
-		"InitialSecret":          initialSecret,
-		"Version":                version.Version,
+		"DisableLoadSecrets": cfg.DisableLoadSecrets,
+		"AuthEnabled":        cfg.OIDC.Enabled(),
+		"EnableApply":        cfg.EnableApply,
+		"WebContext":         cfg.Web.Context,
+		"InitialSecret":      initialSecret,
+		"Version":            version.Version,
 	}
 
 	var tpl bytes.Buffer
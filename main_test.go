@@ -48,7 +48,7 @@ var _ = Describe("Main", func() {
 			ssClient = ssclient.NewMockSealedSecretInterface(mock)
 			coreClient = core.NewMockCoreV1Interface(mock)
 			secrets = core.NewMockSecretInterface(mock)
-			router = setupRouter(coreClient, alpha1Client, cfg, nil)
+			router = setupRouter(coreClient, alpha1Client, cfg, nil, nil)
 		})
 		It("return OK on health", func() {
 			req, _ := http.NewRequest(http.MethodGet, "/_health", http.NoBody)
@@ -63,6 +63,18 @@ var _ = Describe("Main", func() {
 			Ω(w.Body.String()).Should(Equal(`{"build":"","version":"dev"}`))
 		})
 
+		It("return the metrics", func() {
+			req, _ := http.NewRequest(http.MethodGet, "/_health", http.NoBody)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			req, _ = http.NewRequest(http.MethodGet, "/metrics", http.NoBody)
+			router.ServeHTTP(w, req)
+			Ω(w.Code).Should(Equal(http.StatusOK))
+			Ω(w.Body.String()).Should(ContainSubstring(
+				`sealed_secrets_web_http_requests_total{method="GET",route="/_health",status="200"}`,
+			))
+		})
+
 		It("return the index page", func() {
 			req, _ := http.NewRequest(http.MethodGet, "/", http.NoBody)
 			router.ServeHTTP(w, req)
@@ -92,12 +104,12 @@ var _ = Describe("Main", func() {
 			Ω(w.Code).Should(Equal(http.StatusOK))
 			Ω(
 				w.Body.String(),
-			).Should(Equal(fmt.Sprintf(`{"secrets":[{"namespace":%q,"name":%q}]}`, namespace, name)))
+			).Should(Equal(fmt.Sprintf(`{"secrets":[{"namespace":%q,"name":%q}],"total":1}`, namespace, name)))
 		})
 
 		It("list sealed secrets only for given namespaces", func() {
 			cfg.IncludeNamespaces = []string{"a", "b"}
-			router = setupRouter(coreClient, alpha1Client, cfg, nil)
+			router = setupRouter(coreClient, alpha1Client, cfg, nil, nil)
 			alpha1Client.EXPECT().SealedSecrets("a").Return(ssClient)
 			ssClient.EXPECT().List(gomock.Any(), gomock.Any()).Return(&v1alpha1.SealedSecretList{
 				Items: []v1alpha1.SealedSecret{
@@ -121,7 +133,7 @@ var _ = Describe("Main", func() {
 			Ω(w.Code).Should(Equal(http.StatusOK))
 			Ω(
 				w.Body.String(),
-			).Should(Equal(fmt.Sprintf(`{"secrets":[{"namespace":%q,"name":%q},{"namespace":%q,"name":%q}]}`, "a", name, "b", name)))
+			).Should(Equal(fmt.Sprintf(`{"secrets":[{"namespace":%q,"name":%q},{"namespace":%q,"name":%q}],"total":2}`, "a", name, "b", name)))
 		})
 
 		It("get secret from namespace by name", func() {
@@ -144,7 +156,7 @@ var _ = Describe("Main", func() {
 
 		It("secrets endpoints are disabled", func() {
 			cfg.DisableLoadSecrets = true
-			router = setupRouter(coreClient, alpha1Client, cfg, nil)
+			router = setupRouter(coreClient, alpha1Client, cfg, nil, nil)
 			req, _ := http.NewRequest(http.MethodGet, "/api/secrets", http.NoBody)
 			router.ServeHTTP(w, req)
 			Ω(w.Code).Should(Equal(403))
@@ -0,0 +1,99 @@
+package audit
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+)
+
+// Operation is the audited action.
+type Operation string
+
+const (
+	OperationSecretRead Operation = "secret.read"
+	OperationSeal       Operation = "seal"
+	OperationSealRaw    Operation = "seal.raw"
+	OperationMerge      Operation = "merge"
+	OperationApply      Operation = "apply"
+	OperationUnseal     Operation = "unseal"
+	OperationReEncrypt  Operation = "reencrypt"
+)
+
+// Outcome is the result of the audited action.
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+// Event is a single audit record. It contains the names of the secret keys but never their values.
+type Event struct {
+	Time      time.Time `json:"time"`
+	ClientIP  string    `json:"clientIP"`
+	User      string    `json:"user,omitempty"`
+	Groups    []string  `json:"groups,omitempty"`
+	Operation Operation `json:"operation"`
+	Target    string    `json:"target,omitempty"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Keys      []string  `json:"keys,omitempty"`
+	Scope     string    `json:"scope,omitempty"`
+	Outcome   Outcome   `json:"outcome"`
+	Status    int       `json:"status"`
+}
+
+// Sink receives the audit events.
+type Sink interface {
+	Write(e *Event) error
+}
+
+// Logger writes the audit events to all sinks. A nil Logger discards all events.
+type Logger struct {
+	sinks []Sink
+}
+
+// NewLogger creates a logger writing to the given sinks.
+func NewLogger(sinks ...Sink) *Logger {
+	return &Logger{sinks: sinks}
+}
+
+// New creates a logger for the configured sinks. It returns nil if the audit log is not enabled.
+func New(cfg config.Audit) (*Logger, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	var sinks []Sink
+	if cfg.Stdout {
+		sinks = append(sinks, NewWriterSink(os.Stdout))
+	}
+	if cfg.File.Path != "" {
+		fs, err := NewFileSink(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, fs)
+	}
+	if cfg.Webhook.URL != "" {
+		sinks = append(sinks, NewWebhookSink(cfg.Webhook))
+	}
+	return NewLogger(sinks...), nil
+}
+
+// Log writes the event to all sinks. Failing sinks are logged and do not affect the request.
+func (l *Logger) Log(e *Event) {
+	if l == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	for _, s := range l.sinks {
+		if err := s.Write(e); err != nil {
+			log.Printf("Error writing audit event: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Audit", func() {
+	var event *Event
+	BeforeEach(func() {
+		event = &Event{
+			Time:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			ClientIP:  "10.0.0.1",
+			User:      "jane",
+			Operation: OperationSeal,
+			Namespace: "ns",
+			Name:      "name",
+			Keys:      []string{"password", "username"},
+			Scope:     "strict",
+			Outcome:   OutcomeSuccess,
+			Status:    http.StatusOK,
+		}
+	})
+
+	Context("Logger", func() {
+		It("should not be created if disabled", func() {
+			l, err := New(config.Audit{})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(l).Should(BeNil())
+			l.Log(event)
+		})
+		It("should write to all sinks and continue on errors", func() {
+			var buf bytes.Buffer
+			l := NewLogger(failingSink{}, NewWriterSink(&buf))
+			l.Log(event)
+			Ω(buf.String()).Should(ContainSubstring(`"user":"jane"`))
+		})
+		It("should set the time", func() {
+			var buf bytes.Buffer
+			event.Time = time.Time{}
+			NewLogger(NewWriterSink(&buf)).Log(event)
+			Ω(event.Time).ShouldNot(BeZero())
+		})
+	})
+
+	Context("writer sink", func() {
+		It("should write json lines", func() {
+			var buf bytes.Buffer
+			s := NewWriterSink(&buf)
+			Ω(s.Write(event)).Should(Succeed())
+			Ω(s.Write(event)).Should(Succeed())
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			Ω(lines).Should(HaveLen(2))
+			Ω(lines[0]).Should(Equal(`{"time":"2026-01-02T03:04:05Z","clientIP":"10.0.0.1","user":"jane",` +
+				`"operation":"seal","namespace":"ns","name":"name","keys":["password","username"],` +
+				`"scope":"strict","outcome":"success","status":200}`))
+		})
+	})
+
+	Context("file sink", func() {
+		var path string
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "audit.log")
+		})
+		It("should append to the file", func() {
+			s, err := NewFileSink(config.AuditFile{Path: path, MaxSizeMB: 1, MaxBackups: 1})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(s.Write(event)).Should(Succeed())
+
+			b, err := os.ReadFile(path)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(b)).Should(HaveSuffix("}\n"))
+		})
+		It("should rotate the file", func() {
+			rf := &rotatingFile{path: path, maxSize: 10, maxBackups: 2}
+			Ω(rf.open()).Should(Succeed())
+			for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+				_, err := rf.Write([]byte(line))
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+
+			Ω(readFile(path)).Should(Equal("fourth\n"))
+			Ω(readFile(path + ".1")).Should(Equal("third\n"))
+			Ω(readFile(path + ".2")).Should(Equal("second\n"))
+			Ω(path + ".3").ShouldNot(BeAnExistingFile())
+		})
+	})
+
+	Context("webhook sink", func() {
+		type request struct {
+			header http.Header
+			body   []byte
+		}
+		var (
+			server   *httptest.Server
+			status   int
+			received chan request
+		)
+		BeforeEach(func() {
+			status = http.StatusAccepted
+			received = make(chan request, 1)
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				w.WriteHeader(status)
+				received <- request{header: r.Header, body: body}
+			}))
+			DeferCleanup(server.Close)
+		})
+		It("should post the event", func() {
+			s := NewWebhookSink(config.AuditWebhook{
+				URL:     server.URL,
+				Headers: map[string]string{"Authorization": "Bearer token"},
+				Timeout: time.Second,
+			})
+			Ω(s.Write(event)).Should(Succeed())
+
+			var r request
+			Eventually(received).Should(Receive(&r))
+			Ω(r.header.Get("Authorization")).Should(Equal("Bearer token"))
+			Ω(r.header.Get("Content-Type")).Should(Equal("application/json"))
+			e := &Event{}
+			Ω(json.Unmarshal(r.body, e)).Should(Succeed())
+			Ω(e).Should(Equal(event))
+		})
+		It("should fail on error status", func() {
+			status = http.StatusInternalServerError
+			s := newWebhookSink(config.AuditWebhook{URL: server.URL, Timeout: time.Second}, 1)
+			Ω(s.post([]byte("{}"))).Should(MatchError("audit webhook returned status 500"))
+		})
+		It("should drop the events if the queue is full", func() {
+			s := newWebhookSink(config.AuditWebhook{URL: server.URL, Timeout: time.Second}, 1)
+			Ω(s.Write(event)).Should(Succeed())
+			Ω(s.Write(event)).Should(MatchError("audit webhook queue is full, dropping event seal ns/name"))
+		})
+	})
+})
+
+type failingSink struct{}
+
+func (failingSink) Write(*Event) error {
+	return errors.New("failed")
+}
+
+func readFile(path string) string {
+	b, err := os.ReadFile(path)
+	Ω(err).ShouldNot(HaveOccurred())
+	return string(b)
+}
@@ -0,0 +1,172 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+)
+
+// writerSink writes the events as JSON lines.
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink creates a sink writing JSON lines to w.
+func NewWriterSink(w io.Writer) Sink {
+	return &writerSink{w: w}
+}
+
+func (s *writerSink) Write(e *Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
+
+// NewFileSink creates a sink writing JSON lines to a file, that is rotated when reaching the max size.
+func NewFileSink(cfg config.AuditFile) (Sink, error) {
+	rf := &rotatingFile{
+		path:       cfg.Path,
+		maxSize:    int64(cfg.MaxSizeMB) * 1024 * 1024,
+		maxBackups: cfg.MaxBackups,
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return NewWriterSink(rf), nil
+}
+
+// rotatingFile renames the file to path.1 (and the older backups to path.2 ...) when maxSize is exceeded.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	_ = os.Remove(f.backup(f.maxBackups))
+	for i := f.maxBackups - 1; i > 0; i-- {
+		_ = os.Rename(f.backup(i), f.backup(i+1))
+	}
+	if f.maxBackups > 0 {
+		if err := os.Rename(f.path, f.backup(1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(f.path); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+func (f *rotatingFile) backup(i int) string {
+	return fmt.Sprintf("%s.%d", f.path, i)
+}
+
+// webhookQueueSize is the number of events buffered for the webhook before events are dropped.
+const webhookQueueSize = 1000
+
+// webhookSink posts each event as JSON. The events are queued and posted by a background worker, so a slow webhook
+// does not delay the requests.
+type webhookSink struct {
+	cfg    config.AuditWebhook
+	client *http.Client
+	queue  chan []byte
+}
+
+// NewWebhookSink creates a sink posting the events to the configured URL.
+func NewWebhookSink(cfg config.AuditWebhook) Sink {
+	s := newWebhookSink(cfg, webhookQueueSize)
+	go s.run()
+	return s
+}
+
+func newWebhookSink(cfg config.AuditWebhook, queueSize int) *webhookSink {
+	return &webhookSink{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}, queue: make(chan []byte, queueSize)}
+}
+
+// Write queues the event. If the queue is full, the event is dropped and an error is returned.
+func (s *webhookSink) Write(e *Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	select {
+	case s.queue <- b:
+		return nil
+	default:
+		return fmt.Errorf("audit webhook queue is full, dropping event %s %s/%s", e.Operation, e.Namespace, e.Name)
+	}
+}
+
+func (s *webhookSink) run() {
+	for b := range s.queue {
+		if err := s.post(b); err != nil {
+			log.Printf("Error writing audit event: %v\n", err)
+		}
+	}
+}
+
+func (s *webhookSink) post(b []byte) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.cfg.URL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("audit webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
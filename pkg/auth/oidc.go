@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+)
+
+const (
+	PathLogin    = "/auth/login"
+	PathCallback = "/auth/callback"
+	PathLogout   = "/auth/logout"
+
+	cookieSession = "ssw_session"
+	cookieState   = "ssw_oidc_state"
+	cookieNonce   = "ssw_oidc_nonce"
+
+	loginTimeout = 10 * time.Minute
+)
+
+// publicPaths can be accessed without authentication.
+var publicPaths = []string{"/_health", "/metrics", "/static/", "/auth/"}
+
+// OIDC authenticates users with the authorization code flow of an OpenID Connect provider
+// and keeps the user identity in a signed session cookie.
+type OIDC struct {
+	cfg        config.OIDC
+	oauth2     oauth2.Config
+	verifier   *oidc.IDTokenVerifier
+	sessions   *sessionCodec
+	webContext string
+	secure     bool
+}
+
+// NewOIDC creates a new OIDC authenticator by discovering the configured issuer.
+func NewOIDC(ctx context.Context, cfg config.OIDC, webContext string) (*OIDC, error) {
+	provider, err := oidc.NewProvider(ctx, cfg.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not discover oidc issuer %s: %w", cfg.IssuerURL, err)
+	}
+
+	key := []byte(cfg.SessionKey)
+	if len(key) == 0 {
+		log.Println("No OIDC session key configured, sessions are invalidated on restart.")
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return &OIDC{
+		cfg: cfg,
+		oauth2: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       cfg.Scopes,
+		},
+		verifier:   provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+		sessions:   &sessionCodec{key: key},
+		webContext: webContext,
+		secure:     strings.HasPrefix(cfg.RedirectURL, "https://"),
+	}, nil
+}
+
+// Register adds the login, callback and logout routes.
+func (o *OIDC) Register(r gin.IRoutes) {
+	r.GET(PathLogin, o.Login)
+	r.GET(PathCallback, o.Callback)
+	r.GET(PathLogout, o.Logout)
+}
+
+// Middleware stores the user of a valid session in the context.
+// Unauthenticated api calls are rejected with 401, pages are redirected to the login.
+func (o *OIDC) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, p := range publicPaths {
+			if strings.HasPrefix(c.Request.URL.Path, p) {
+				c.Next()
+				return
+			}
+		}
+
+		if value, err := c.Cookie(cookieSession); err == nil {
+			if s, err := o.sessions.decode(value); err == nil {
+				SetUser(c, &s.User)
+				c.Next()
+				return
+			}
+		}
+
+		if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		c.Redirect(http.StatusFound, o.webContext+strings.TrimPrefix(PathLogin, "/"))
+		c.Abort()
+	}
+}
+
+// Login redirects to the authorization endpoint of the provider.
+func (o *OIDC) Login(c *gin.Context) {
+	state, err := randomString()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	nonce, err := randomString()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	o.setCookie(c, cookieState, state, loginTimeout)
+	o.setCookie(c, cookieNonce, nonce, loginTimeout)
+	c.Redirect(http.StatusFound, o.oauth2.AuthCodeURL(state, oidc.Nonce(nonce)))
+}
+
+// Callback exchanges the authorization code, verifies the id token and starts the session.
+func (o *OIDC) Callback(c *gin.Context) {
+	user, err := o.authenticate(c)
+	if err != nil {
+		log.Printf("OIDC login failed: %v\n", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	value, err := o.sessions.encode(&session{User: *user, Expires: time.Now().Add(o.cfg.SessionTTL).Unix()})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	o.clearCookie(c, cookieState)
+	o.clearCookie(c, cookieNonce)
+	o.setCookie(c, cookieSession, value, o.cfg.SessionTTL)
+	c.Redirect(http.StatusFound, o.webContext)
+}
+
+// Logout ends the session.
+func (o *OIDC) Logout(c *gin.Context) {
+	o.clearCookie(c, cookieSession)
+	c.Redirect(http.StatusFound, o.webContext)
+}
+
+func (o *OIDC) authenticate(c *gin.Context) (*User, error) {
+	if e := c.Query("error"); e != "" {
+		return nil, fmt.Errorf("%s: %s", e, c.Query("error_description"))
+	}
+
+	state, err := c.Cookie(cookieState)
+	if err != nil || state == "" || state != c.Query("state") {
+		return nil, errors.New("invalid state")
+	}
+
+	token, err := o.oauth2.Exchange(c, c.Query("code"))
+	if err != nil {
+		return nil, err
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("no id_token in token response")
+	}
+	idToken, err := o.verifier.Verify(c, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := c.Cookie(cookieNonce)
+	if err != nil || nonce == "" || idToken.Nonce != nonce {
+		return nil, errors.New("invalid nonce")
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+	return o.userFromClaims(claims, idToken.Subject), nil
+}
+
+func (o *OIDC) userFromClaims(claims map[string]any, subject string) *User {
+	user := &User{Name: subject}
+	if name, ok := claims[o.cfg.UsernameClaim].(string); ok && name != "" {
+		user.Name = name
+	}
+	if groups, ok := claims[o.cfg.GroupsClaim].([]any); ok {
+		for _, g := range groups {
+			if group, ok := g.(string); ok {
+				user.Groups = append(user.Groups, group)
+			}
+		}
+	}
+	return user
+}
+
+func (o *OIDC) setCookie(c *gin.Context, name, value string, maxAge time.Duration) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(name, value, int(maxAge.Seconds()), "/", "", o.secure, true)
+}
+
+func (o *OIDC) clearCookie(c *gin.Context, name string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(name, "", -1, "/", "", o.secure, true)
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
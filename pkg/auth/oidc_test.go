@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const testClientID = "sealed-secrets-web"
+
+var _ = Describe("OIDC", func() {
+	var (
+		issuer *mockIssuer
+		router *gin.Engine
+	)
+	BeforeEach(func() {
+		gin.SetMode(gin.ReleaseMode)
+		issuer = newMockIssuer()
+		DeferCleanup(issuer.Close)
+
+		o, err := NewOIDC(context.Background(), config.OIDC{
+			IssuerURL:     issuer.URL,
+			ClientID:      testClientID,
+			ClientSecret:  "secret",
+			RedirectURL:   "http://localhost/auth/callback",
+			Scopes:        []string{"openid", "email"},
+			UsernameClaim: "email",
+			GroupsClaim:   "groups",
+			SessionTTL:    time.Hour,
+		}, "/ssw/")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		router = gin.New()
+		router.Use(o.Middleware())
+		o.Register(router)
+		router.GET("/", func(c *gin.Context) { c.String(http.StatusOK, "index") })
+		router.GET("/_health", func(c *gin.Context) { c.String(http.StatusOK, "OK") })
+		router.GET("/api/user", func(c *gin.Context) {
+			user, _ := UserFrom(c)
+			c.JSON(http.StatusOK, user)
+		})
+	})
+
+	serve := func(req *http.Request, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	login := func() []*http.Cookie {
+		req, _ := http.NewRequest(http.MethodGet, PathLogin, http.NoBody)
+		w := serve(req)
+		Ω(w.Code).Should(Equal(http.StatusFound))
+		location, err := url.Parse(w.Header().Get("Location"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(location.Path).Should(Equal("/authorize"))
+		issuer.nonce = location.Query().Get("nonce")
+
+		req, _ = http.NewRequest(
+			http.MethodGet,
+			PathCallback+"?code=the-code&state="+location.Query().Get("state"),
+			http.NoBody,
+		)
+		w = serve(req, w.Result().Cookies()...)
+		Ω(w.Code).Should(Equal(http.StatusFound))
+		Ω(w.Header().Get("Location")).Should(Equal("/ssw/"))
+		return w.Result().Cookies()
+	}
+
+	sessionCookie := func(cookies []*http.Cookie) *http.Cookie {
+		for _, c := range cookies {
+			if c.Name == cookieSession {
+				return c
+			}
+		}
+		Fail("no session cookie")
+		return nil
+	}
+
+	It("should allow public paths without authentication", func() {
+		req, _ := http.NewRequest(http.MethodGet, "/_health", http.NoBody)
+		Ω(serve(req).Code).Should(Equal(http.StatusOK))
+	})
+
+	It("should redirect pages to the login", func() {
+		req, _ := http.NewRequest(http.MethodGet, "/", http.NoBody)
+		w := serve(req)
+		Ω(w.Code).Should(Equal(http.StatusFound))
+		Ω(w.Header().Get("Location")).Should(Equal("/ssw/auth/login"))
+	})
+
+	It("should reject api calls with 401", func() {
+		req, _ := http.NewRequest(http.MethodGet, "/api/user", http.NoBody)
+		w := serve(req)
+		Ω(w.Code).Should(Equal(http.StatusUnauthorized))
+		Ω(w.Body.String()).Should(Equal(`{"error":"authentication required"}`))
+	})
+
+	It("should login and provide the user to the handlers", func() {
+		session := sessionCookie(login())
+
+		req, _ := http.NewRequest(http.MethodGet, "/api/user", http.NoBody)
+		w := serve(req, session)
+		Ω(w.Code).Should(Equal(http.StatusOK))
+		Ω(w.Body.String()).Should(Equal(`{"name":"jane@example.com","groups":["devs","ops"]}`))
+	})
+
+	It("should reject a tampered session", func() {
+		session := sessionCookie(login())
+		session.Value = "x" + session.Value
+
+		req, _ := http.NewRequest(http.MethodGet, "/api/user", http.NoBody)
+		Ω(serve(req, session).Code).Should(Equal(http.StatusUnauthorized))
+	})
+
+	It("should reject a callback with an invalid state", func() {
+		req, _ := http.NewRequest(http.MethodGet, PathCallback+"?code=the-code&state=foo", http.NoBody)
+		w := serve(req, &http.Cookie{Name: cookieState, Value: "bar"})
+		Ω(w.Code).Should(Equal(http.StatusUnauthorized))
+		Ω(w.Body.String()).Should(Equal(`{"error":"invalid state"}`))
+	})
+
+	It("should reject an id token with an invalid nonce", func() {
+		req, _ := http.NewRequest(http.MethodGet, PathLogin, http.NoBody)
+		w := serve(req)
+		location, err := url.Parse(w.Header().Get("Location"))
+		Ω(err).ShouldNot(HaveOccurred())
+		issuer.nonce = "other"
+
+		req, _ = http.NewRequest(
+			http.MethodGet,
+			PathCallback+"?code=the-code&state="+location.Query().Get("state"),
+			http.NoBody,
+		)
+		w = serve(req, w.Result().Cookies()...)
+		Ω(w.Code).Should(Equal(http.StatusUnauthorized))
+		Ω(w.Body.String()).Should(Equal(`{"error":"invalid nonce"}`))
+	})
+
+	It("should logout", func() {
+		req, _ := http.NewRequest(http.MethodGet, PathLogout, http.NoBody)
+		w := serve(req)
+		Ω(w.Code).Should(Equal(http.StatusFound))
+		cookie := sessionCookie(w.Result().Cookies())
+		Ω(cookie.MaxAge).Should(BeNumerically("<", 0))
+	})
+})
+
+var _ = Describe("session", func() {
+	var sc *sessionCodec
+	BeforeEach(func() {
+		sc = &sessionCodec{key: []byte("key")}
+	})
+	It("should encode and decode a session", func() {
+		value, err := sc.encode(&session{User: User{Name: "jane"}, Expires: time.Now().Add(time.Minute).Unix()})
+		Ω(err).ShouldNot(HaveOccurred())
+		s, err := sc.decode(value)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(s.User.Name).Should(Equal("jane"))
+	})
+	It("should reject a session signed with another key", func() {
+		value, err := sc.encode(&session{User: User{Name: "jane"}, Expires: time.Now().Add(time.Minute).Unix()})
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = (&sessionCodec{key: []byte("other")}).decode(value)
+		Ω(err).Should(MatchError(errInvalidSession))
+	})
+	It("should reject an expired session", func() {
+		value, err := sc.encode(&session{User: User{Name: "jane"}, Expires: time.Now().Add(-time.Minute).Unix()})
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = sc.decode(value)
+		Ω(err).Should(MatchError(errExpiredSession))
+	})
+})
+
+// mockIssuer is a minimal OIDC provider issuing signed id tokens for any authorization code.
+type mockIssuer struct {
+	*httptest.Server
+	key   *rsa.PrivateKey
+	nonce string
+}
+
+func newMockIssuer() *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Ω(err).ShouldNot(HaveOccurred())
+	m := &mockIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, map[string]any{
+			"issuer":                                m.URL,
+			"authorization_endpoint":                m.URL + "/authorize",
+			"token_endpoint":                        m.URL + "/token",
+			"jwks_uri":                              m.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, map[string]any{"keys": []map[string]any{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, map[string]any{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token": m.idToken(map[string]any{
+				"iss":    m.URL,
+				"sub":    "jane",
+				"aud":    testClientID,
+				"iat":    time.Now().Unix(),
+				"exp":    time.Now().Add(time.Hour).Unix(),
+				"nonce":  m.nonce,
+				"email":  "jane@example.com",
+				"groups": []string{"devs", "ops"},
+			}),
+		})
+	})
+	m.Server = httptest.NewServer(mux)
+	return m
+}
+
+func (m *mockIssuer) idToken(claims map[string]any) string {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	Ω(err).ShouldNot(HaveOccurred())
+	payload, err := json.Marshal(claims)
+	Ω(err).ShouldNot(HaveOccurred())
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, digest[:])
+	Ω(err).ShouldNot(HaveOccurred())
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func writeJSON(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(data)
+}
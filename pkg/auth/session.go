@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	errInvalidSession = errors.New("invalid session")
+	errExpiredSession = errors.New("session expired")
+)
+
+// session is the content of the signed session cookie.
+type session struct {
+	User    User  `json:"user"`
+	Expires int64 `json:"exp"`
+}
+
+// sessionCodec signs and verifies session cookies with HMAC-SHA256.
+type sessionCodec struct {
+	key []byte
+}
+
+func (sc *sessionCodec) encode(s *session) (string, error) {
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	p := base64.RawURLEncoding.EncodeToString(payload)
+	return p + "." + base64.RawURLEncoding.EncodeToString(sc.sign(p)), nil
+}
+
+func (sc *sessionCodec) decode(value string) (*session, error) {
+	p, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, errInvalidSession
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(signature, sc.sign(p)) {
+		return nil, errInvalidSession
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(p)
+	if err != nil {
+		return nil, errInvalidSession
+	}
+	s := &session{}
+	if err := json.Unmarshal(payload, s); err != nil {
+		return nil, errInvalidSession
+	}
+	if time.Now().Unix() > s.Expires {
+		return nil, errExpiredSession
+	}
+	return s, nil
+}
+
+func (sc *sessionCodec) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, sc.key)
+	_, _ = mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// contextKeyUser is the key the authenticated user is stored with in the gin context.
+const contextKeyUser = "sealed-secrets-web/user"
+
+// User is the identity of the authenticated user.
+type User struct {
+	Name   string   `json:"name"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// SetUser stores the authenticated user in the gin context.
+func SetUser(c *gin.Context, user *User) {
+	c.Set(contextKeyUser, user)
+}
+
+// UserFrom returns the authenticated user from the gin context.
+func UserFrom(c *gin.Context) (*User, bool) {
+	v, ok := c.Get(contextKeyUser)
+	if !ok {
+		return nil, false
+	}
+	user, ok := v.(*User)
+	return user, ok && user != nil
+}
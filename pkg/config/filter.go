@@ -1,30 +1,117 @@
 package config
 
-import "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+)
 
+// FieldFilter removes fields from the secrets and sealed secrets returned to the user.
+// The segments of a path are glob patterns with the syntax of path.Match, where '*' matches any sequence of
+// characters except '/', e.g. ["metadata", "annotations", "kubectl.kubernetes.io/*"].
 type FieldFilter struct {
 	Skip      [][]string `yaml:"skip"`
 	SkipIfNil [][]string `yaml:"skipIfNil"`
+	// Keep protects the matching fields from being removed by Skip and SkipIfNil.
+	Keep []KeepRule `yaml:"keep,omitempty"`
+}
+
+// KeepRule keeps the fields matching the path and, if defined, the value.
+type KeepRule struct {
+	Path  []string `yaml:"path"`
+	Value string   `yaml:"value,omitempty"` // glob pattern the value must match; empty matches any value
+}
+
+// DefaultFieldFilter returns the filter used if none is configured. It removes the empty fields of the sealing output.
+func DefaultFieldFilter() *FieldFilter {
+	return &FieldFilter{
+		Skip: [][]string{},
+		SkipIfNil: [][]string{
+			{"metadata", "creationTimestamp"},
+			{"spec", "template", "data"},
+			{"spec", "template", "metadata", "creationTimestamp"},
+		},
+	}
 }
 
 func (ff *FieldFilter) Apply(sec map[string]any) {
 	for _, fieldPath := range ff.Skip {
-		unstructured.RemoveNestedField(sec, fieldPath...)
+		ff.remove(sec, fieldPath, nil, false)
 	}
 
 	for _, fieldPath := range ff.SkipIfNil {
-		removeFieldIfNull(sec, fieldPath...)
+		ff.remove(sec, fieldPath, nil, true)
 	}
 }
 
-func removeFieldIfNull(sec map[string]any, fields ...string) {
-	path := fields[:len(fields)-1]
-	name := fields[len(fields)-1]
-	if m, ok, _ := unstructured.NestedMap(sec, path...); ok {
-		f := m[name]
-		if f == nil {
+// remove deletes the fields matching the path from the map, unless they are kept.
+func (ff *FieldFilter) remove(m map[string]any, path, parent []string, onlyNil bool) {
+	if len(path) == 0 {
+		return
+	}
+	// sorted to get a deterministic order of the nested removals
+	for _, name := range slices.Sorted(maps.Keys(m)) {
+		if !matchGlob(path[0], name) {
+			continue
+		}
+		fieldPath := append(slices.Clone(parent), name)
+		value := m[name]
+		if len(path) > 1 {
+			if sub, ok := value.(map[string]any); ok {
+				ff.remove(sub, path[1:], fieldPath, onlyNil)
+			}
+			continue
+		}
+		if (!onlyNil || value == nil) && !ff.keep(fieldPath, value) {
 			delete(m, name)
-			_ = unstructured.SetNestedMap(sec, m, path...)
 		}
 	}
 }
+
+// keep checks if a keep rule matches the field.
+func (ff *FieldFilter) keep(fieldPath []string, value any) bool {
+	for _, rule := range ff.Keep {
+		if len(rule.Path) != len(fieldPath) {
+			continue
+		}
+		matched := true
+		for i := range rule.Path {
+			if !matchGlob(rule.Path[i], fieldPath[i]) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		if rule.Value == "" {
+			return true
+		}
+		if value != nil && matchGlob(rule.Value, fmt.Sprint(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the glob patterns of the filter.
+func (ff *FieldFilter) Validate() error {
+	patterns := slices.Concat(ff.Skip, ff.SkipIfNil)
+	for _, rule := range ff.Keep {
+		patterns = append(patterns, append(slices.Clone(rule.Path), rule.Value))
+	}
+	for _, pattern := range slices.Concat(patterns...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid field filter pattern '%s': %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchGlob matches the name against the pattern with the syntax of path.Match, like the patterns of the policy.
+// Invalid patterns are rejected when loading the config and do not match.
+func matchGlob(pattern, name string) bool {
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
@@ -60,6 +60,76 @@ var _ = Describe("Filter", func() {
 			Ω(test.SubMap(secretData, "spec", "template")).Should(HaveKey("data"))
 		})
 	})
+	Context("glob paths", func() {
+		var secretData map[string]any
+		BeforeEach(func() {
+			secretData = map[string]any{
+				"metadata": map[string]any{
+					"annotations": map[string]any{
+						"kubectl.kubernetes.io/last-applied-configuration": "{}",
+						"kubectl.kubernetes.io/restartedAt":                "now",
+						"sealedsecrets.bitnami.com/managed":                "true",
+						"sealedsecrets.bitnami.com/patch":                  "false",
+						"foo":                                              "bar",
+					},
+					"labels": map[string]any{"app": "web"},
+				},
+			}
+		})
+		It("should remove the fields matching the glob", func() {
+			ff := &FieldFilter{Skip: [][]string{{"metadata", "annotations", "kubectl.kubernetes.io/*"}}}
+			ff.Apply(secretData)
+
+			Ω(test.SubMap(secretData, "metadata", "annotations")).Should(HaveLen(3))
+			Ω(test.SubMap(secretData, "metadata", "annotations")).Should(HaveKey("foo"))
+		})
+		It("should match the glob on any level", func() {
+			ff := &FieldFilter{Skip: [][]string{{"metadata", "*", "app"}}}
+			ff.Apply(secretData)
+
+			Ω(test.SubMap(secretData, "metadata", "labels")).Should(BeEmpty())
+		})
+		It("should keep the fields matching a keep rule", func() {
+			ff := &FieldFilter{
+				Skip: [][]string{{"metadata", "annotations", "*"}, {"metadata", "annotations", "*/*"}},
+				Keep: []KeepRule{
+					{Path: []string{"metadata", "annotations", "sealedsecrets.bitnami.com/*"}, Value: "true"},
+					{Path: []string{"metadata", "annotations", "fo?"}},
+				},
+			}
+			ff.Apply(secretData)
+
+			Ω(test.SubMap(secretData, "metadata", "annotations")).Should(Equal(map[string]any{
+				"sealedsecrets.bitnami.com/managed": "true",
+				"foo":                               "bar",
+			}))
+		})
+		DescribeTable("matchGlob",
+			func(pattern, name string, match bool) {
+				Ω(matchGlob(pattern, name)).Should(Equal(match))
+			},
+			Entry("literal", "uid", "uid", true),
+			Entry("other literal", "uid", "uuid", false),
+			Entry("star", "kubectl.kubernetes.io/*", "kubectl.kubernetes.io/last-applied-configuration", true),
+			Entry("star across dots", "*", "a.b", true),
+			Entry("star does not cross slashes", "*", "a/b", false),
+			Entry("character class", "[ab]c", "bc", true),
+			Entry("escaped star", `a\*`, "a*", true),
+			Entry("invalid pattern", "[", "[", false),
+			Entry("star in the middle", "a*c", "abbbc", true),
+			Entry("question mark", "a?c", "abc", true),
+			Entry("question mark needs a character", "a?c", "ac", false),
+		)
+	})
+	Context("Validate", func() {
+		It("should accept the default filter", func() {
+			Ω(DefaultFieldFilter().Validate()).Should(Succeed())
+		})
+		It("should reject invalid patterns", func() {
+			ff := &FieldFilter{Keep: []KeepRule{{Path: []string{"metadata", "labels", "a"}, Value: "[b"}}}
+			Ω(ff.Validate()).Should(MatchError(ContainSubstring("invalid field filter pattern '[b'")))
+		})
+	})
 	Context("removeRuntimeFields", func() {
 		var ff *FieldFilter
 		BeforeEach(func() {
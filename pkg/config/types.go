@@ -2,12 +2,14 @@ package config
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -20,14 +22,18 @@ func parseInternal(f *flags) (*Config, error) {
 	flag.Parse()
 	cfg := &Config{
 		Web: Web{
-			Port:    *f.port,
-			Context: *f.webContext,
-			Logger:  *f.enableWebLogs,
+			Port:        *f.port,
+			Context:     *f.webContext,
+			Logger:      *f.enableWebLogs,
+			MetricsPort: *f.metricsPort,
 		},
 		PrintVersion:          *f.printVersion,
 		DisableLoadSecrets:    *f.disableLoadSecrets,
 		ShowOnlySyncedSecrets: *f.showOnlySyncedSecrets,
 		UseRegex:              *f.useRegex,
+		EnableApply:           *f.enableApply,
+		Impersonation:         Impersonation{Enabled: *f.impersonateUsers},
+		Audit:                 Audit{Enabled: *f.enableAuditLog},
 	}
 
 	if *f.kubesealArgs != "" {
@@ -42,12 +48,20 @@ func parseInternal(f *flags) (*Config, error) {
 	if *f.sealedSecretsCertURL != "" {
 		cfg.SealedSecrets.CertURL = *f.sealedSecretsCertURL
 	}
+	if *f.sealedSecretsCertFile != "" {
+		cfg.SealedSecrets.CertFile = *f.sealedSecretsCertFile
+	}
+	if *f.sealedSecretsVerifyURL != "" {
+		cfg.SealedSecrets.VerifyURL = *f.sealedSecretsVerifyURL
+	}
 	if *f.sealedSecretsServiceName != "" {
 		cfg.SealedSecrets.Service = *f.sealedSecretsServiceName
 	}
 	if *f.sealedSecretsServiceNamespace != "" {
 		cfg.SealedSecrets.Namespace = *f.sealedSecretsServiceNamespace
 	}
+	cfg.SealedSecrets.CertRefreshInterval = new(*f.sealedSecretsCertRefreshInterval)
+	cfg.SealedSecrets.ReadControllerKeys = *f.sealedSecretsReadControllerKeys
 	if *f.excludeNamespaces != "" {
 		cfg.ExcludeNamespaces = strings.Split(*f.excludeNamespaces, " ")
 	}
@@ -74,15 +88,22 @@ func parseInternal(f *flags) (*Config, error) {
 		}
 	}
 
+	if err := prepareTargets(cfg); err != nil {
+		return nil, err
+	}
+	prepareOIDC(&cfg.OIDC)
+	prepareImpersonation(&cfg.Impersonation)
+	prepareAudit(&cfg.Audit)
+	prepareUnmanagedSecrets(&cfg.UnmanagedSecrets)
+	if err := validateUnseal(cfg); err != nil {
+		return nil, err
+	}
+
 	if cfg.FieldFilter == nil {
-		cfg.FieldFilter = &FieldFilter{
-			Skip: [][]string{},
-			SkipIfNil: [][]string{
-				{"metadata", "creationTimestamp"},
-				{"spec", "template", "data"},
-				{"spec", "template", "metadata", "creationTimestamp"},
-			},
-		}
+		cfg.FieldFilter = DefaultFieldFilter()
+	}
+	if err := cfg.FieldFilter.Validate(); err != nil {
+		return nil, err
 	}
 
 	cfg.Web.Context = sanitizeWebContext(cfg)
@@ -115,6 +136,124 @@ func parseInternal(f *flags) (*Config, error) {
 	return cfg, nil
 }
 
+// prepareTargets validates the configured sealing targets. If targets are defined, the first one is the default
+// and replaces the single sealedSecrets configuration.
+func prepareTargets(cfg *Config) error {
+	if len(cfg.Targets) == 0 {
+		if cfg.SealedSecrets.Name == "" {
+			cfg.SealedSecrets.Name = DefaultTargetName
+		}
+		return nil
+	}
+
+	names := make(map[string]bool)
+	for i := range cfg.Targets {
+		t := &cfg.Targets[i]
+		if t.Name == "" {
+			return fmt.Errorf("sealing target %d has no name", i)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("sealing target '%s' is defined more than once", t.Name)
+		}
+		names[t.Name] = true
+		// inherit the defaults of the single sealedSecrets configuration
+		if t.Service == "" {
+			t.Service = cfg.SealedSecrets.Service
+		}
+		if t.Namespace == "" {
+			t.Namespace = cfg.SealedSecrets.Namespace
+		}
+		if t.CertRefreshInterval == nil {
+			t.CertRefreshInterval = cfg.SealedSecrets.CertRefreshInterval
+		}
+	}
+	cfg.SealedSecrets = cfg.Targets[0]
+	return nil
+}
+
+// prepareOIDC sets the defaults of the OIDC login.
+func prepareOIDC(o *OIDC) {
+	if !o.Enabled() {
+		return
+	}
+	if o.ClientSecret == "" {
+		o.ClientSecret = os.Getenv(envOIDCClientSecret)
+	}
+	if len(o.Scopes) == 0 {
+		o.Scopes = []string{"openid", "profile", "email"}
+	}
+	if o.UsernameClaim == "" {
+		o.UsernameClaim = "email"
+	}
+	if o.GroupsClaim == "" {
+		o.GroupsClaim = "groups"
+	}
+	if o.SessionTTL == 0 {
+		o.SessionTTL = 8 * time.Hour
+	}
+}
+
+// prepareImpersonation sets the default headers of the user identity. The headers are only trusted
+// with impersonation enabled, so they are left empty otherwise.
+func prepareImpersonation(i *Impersonation) {
+	if !i.Enabled {
+		return
+	}
+	if i.UserHeader == "" {
+		i.UserHeader = "X-Forwarded-User"
+	}
+	if i.GroupsHeader == "" {
+		i.GroupsHeader = "X-Forwarded-Groups"
+	}
+}
+
+// prepareAudit sets the defaults of the audit sinks. Without any configured sink, the events are written to stdout.
+func prepareAudit(a *Audit) {
+	if !a.Enabled {
+		return
+	}
+	if !a.Stdout && a.File.Path == "" && a.Webhook.URL == "" {
+		a.Stdout = true
+	}
+	if a.File.MaxSizeMB == 0 {
+		a.File.MaxSizeMB = 100
+	}
+	if a.File.MaxBackups == 0 {
+		a.File.MaxBackups = 5
+	}
+	if a.Webhook.Timeout == 0 {
+		a.Webhook.Timeout = 5 * time.Second
+	}
+}
+
+// prepareUnmanagedSecrets sets the default secret types that are skipped in the unmanaged secrets report.
+func prepareUnmanagedSecrets(u *UnmanagedSecrets) {
+	if u.SkipTypes == nil {
+		u.SkipTypes = []string{
+			"kubernetes.io/service-account-token",
+			"bootstrap.kubernetes.io/token",
+			"helm.sh/release.v1",
+		}
+	}
+}
+
+// validateUnseal checks that the users of the break-glass unsealing can be identified, by the OIDC login or the
+// headers of a trusted proxy with impersonation.
+func validateUnseal(cfg *Config) error {
+	if cfg.Unseal.Enabled && !cfg.OIDC.Enabled() && !cfg.Impersonation.Enabled {
+		return errors.New("unsealing requires the OIDC login or impersonation to identify the users")
+	}
+	return nil
+}
+
+// SealingTargets returns all sealing targets, the default target being the first one.
+func (cfg *Config) SealingTargets() []SealedSecrets {
+	if len(cfg.Targets) > 0 {
+		return cfg.Targets
+	}
+	return []SealedSecrets{cfg.SealedSecrets}
+}
+
 func sanitizeWebContext(cfg *Config) string {
 	wc := cfg.Web.Context
 	if !strings.HasPrefix(wc, "/") &&
@@ -139,47 +278,170 @@ type Config struct {
 	IncludeNamespacesRegex []*regexp.Regexp `yaml:"-"`
 	ExcludeNamespacesRegex []*regexp.Regexp `yaml:"-"`
 	UseRegex               bool             `yaml:"useRegex"`
+	EnableApply            bool             `yaml:"enableApply"`
 	SealedSecrets          SealedSecrets    `yaml:"sealedSecrets"`
+	Targets                []SealedSecrets  `yaml:"targets,omitempty"`
 	InitialSecret          string           `yaml:"initialSecret"`
+	OIDC                   OIDC             `yaml:"oidc,omitempty"`
+	Impersonation          Impersonation    `yaml:"impersonation,omitempty"`
+	Audit                  Audit            `yaml:"audit,omitempty"`
+	UnmanagedSecrets       UnmanagedSecrets `yaml:"unmanagedSecrets,omitempty"`
+	PolicyFile             string           `yaml:"policyFile,omitempty"` // rules evaluated before sealing
+	Unseal                 Unseal           `yaml:"unseal,omitempty"`
 	Ctx                    context.Context  `yaml:"-"` //nolint:containedctx
 }
 
 type Web struct {
-	Port    int    `yaml:"port"`
-	Context string `yaml:"context"`
-	Logger  bool   `yaml:"logger"`
+	Port        int    `yaml:"port"`
+	Context     string `yaml:"context"`
+	Logger      bool   `yaml:"logger"`
+	MetricsPort int    `yaml:"metricsPort,omitempty"` // serve /metrics on a separate port; 0 serves it on the web port
+}
+
+const envOIDCClientSecret = "OIDC_CLIENT_SECRET"
+
+// OIDC configures the optional login with an OpenID Connect provider.
+type OIDC struct {
+	IssuerURL    string `yaml:"issuerURL"`
+	ClientID     string `yaml:"clientID"`
+	ClientSecret string `yaml:"clientSecret"` // if empty, read from env OIDC_CLIENT_SECRET
+	// RedirectURL is the external URL of the callback endpoint e.g. https://ssw.example.com/auth/callback
+	RedirectURL   string        `yaml:"redirectURL"`
+	Scopes        []string      `yaml:"scopes,omitempty"`
+	UsernameClaim string        `yaml:"usernameClaim,omitempty"`
+	GroupsClaim   string        `yaml:"groupsClaim,omitempty"`
+	SessionKey    string        `yaml:"sessionKey,omitempty"` // key to sign the session cookie; random if empty
+	SessionTTL    time.Duration `yaml:"sessionTTL,omitempty"`
+}
+
+// Enabled returns true if an OIDC issuer is configured.
+func (o OIDC) Enabled() bool {
+	return o.IssuerURL != ""
+}
+
+// Impersonation configures the access to secrets with the identity of the requesting user.
+// The user is taken from the OIDC session or from the headers set by a trusted authenticating proxy.
+type Impersonation struct {
+	Enabled      bool   `yaml:"enabled"`
+	UserHeader   string `yaml:"userHeader,omitempty"`   // default X-Forwarded-User
+	GroupsHeader string `yaml:"groupsHeader,omitempty"` // default X-Forwarded-Groups, comma separated
 }
 
+// Audit configures the audit log of secret read and seal operations.
+type Audit struct {
+	Enabled bool         `yaml:"enabled"`
+	Stdout  bool         `yaml:"stdout,omitempty"`
+	File    AuditFile    `yaml:"file,omitempty"`
+	Webhook AuditWebhook `yaml:"webhook,omitempty"`
+}
+
+// AuditFile writes the audit events to a file, that is rotated when reaching MaxSizeMB.
+type AuditFile struct {
+	Path       string `yaml:"path"`
+	MaxSizeMB  int    `yaml:"maxSizeMB,omitempty"`
+	MaxBackups int    `yaml:"maxBackups,omitempty"`
+}
+
+// AuditWebhook posts each audit event as JSON to the URL.
+type AuditWebhook struct {
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Timeout time.Duration     `yaml:"timeout,omitempty"`
+}
+
+// UnmanagedSecrets configures the report of the secrets that are not managed by a SealedSecret.
+type UnmanagedSecrets struct {
+	// SkipTypes are the secret types that are not reported, by default service account tokens,
+	// bootstrap tokens and helm releases.
+	SkipTypes []string `yaml:"skipTypes,omitempty"`
+}
+
+// Unseal configures the break-glass decryption of sealed secrets with backed-up private keys of the controller.
+// It is disabled by default.
+type Unseal struct {
+	Enabled bool `yaml:"enabled"`
+	// KeysDir is the directory of the PEM encoded private keys.
+	KeysDir string `yaml:"keysDir,omitempty"`
+	// AllowUpload allows to send the private key with the request.
+	AllowUpload bool `yaml:"allowUpload,omitempty"`
+	// Groups restricts unsealing to the members of the groups, identified by the login or the impersonation headers.
+	Groups []string `yaml:"groups,omitempty"`
+}
+
+// DefaultTargetName is the name of the sealing target if no targets are configured.
+const DefaultTargetName = "default"
+
 type SealedSecrets struct {
+	Name      string `yaml:"name,omitempty"`
+	Context   string `yaml:"context,omitempty"`
 	Service   string `yaml:"service"`
 	Namespace string `yaml:"namespace"`
 	CertURL   string `yaml:"certURL,omitempty"`
+	CertFile  string `yaml:"certFile,omitempty"`
+	// CertRefreshInterval is the interval to refresh the certificate, 0 disables the refresh.
+	// A target without an interval inherits the one of the sealedSecrets configuration.
+	CertRefreshInterval *time.Duration `yaml:"certRefreshInterval,omitempty"`
+	AdditionalCerts     []string       `yaml:"additionalCerts,omitempty"` // PEM files of further (e.g. rotated) certificates
+	VerifyURL           string         `yaml:"verifyURL,omitempty"`       // verify endpoint of the controller (/v1/verify)
+	// ReadControllerKeys reads the certificates of the key Secrets of the controller, to inspect sealed secrets.
+	// Requires the list permission on secrets in the namespace of the controller.
+	ReadControllerKeys bool `yaml:"readControllerKeys,omitempty"`
 }
 
 func (ss SealedSecrets) String() string {
+	if ss.CertFile != "" {
+		return "Cert file: " + ss.CertFile
+	}
 	if ss.CertURL != "" {
 		return "Cert URL: " + ss.CertURL
 	}
 	return fmt.Sprintf("Namespace: %s / ServiceName: %s", ss.Namespace, ss.Service)
 }
 
+// RefreshInterval returns the interval to refresh the certificate, 0 if the refresh is disabled.
+func (ss SealedSecrets) RefreshInterval() time.Duration {
+	if ss.CertRefreshInterval == nil {
+		return 0
+	}
+	return *ss.CertRefreshInterval
+}
+
+// CanValidate returns true if the sealed secrets controller is reachable via the in cluster service.
+func (ss SealedSecrets) CanValidate() bool {
+	return ss.CertURL == "" && ss.CertFile == ""
+}
+
+// CanVerify returns true if the validation decrypts the sealed secrets with the controller, via the in cluster
+// service or the verify URL. Otherwise, only the structure of the sealed secrets is validated.
+func (ss SealedSecrets) CanVerify() bool {
+	return ss.CanValidate() || ss.VerifyURL != ""
+}
+
 type flags struct {
-	disableLoadSecrets            *bool
-	showOnlySyncedSecrets         *bool
-	enableWebLogs                 *bool
-	includeNamespaces             *string
-	excludeNamespaces             *string
-	useRegex                      *bool
-	kubesealArgs                  *string
-	sealedSecretsServiceName      *string
-	port                          *int
-	config                        *string
-	printVersion                  *bool
-	webContext                    *string
-	webExternalURL                *string
-	initialSecretFile             *string
-	sealedSecretsCertURL          *string
-	sealedSecretsServiceNamespace *string
+	disableLoadSecrets               *bool
+	showOnlySyncedSecrets            *bool
+	enableWebLogs                    *bool
+	includeNamespaces                *string
+	excludeNamespaces                *string
+	useRegex                         *bool
+	kubesealArgs                     *string
+	sealedSecretsServiceName         *string
+	port                             *int
+	config                           *string
+	printVersion                     *bool
+	webContext                       *string
+	webExternalURL                   *string
+	initialSecretFile                *string
+	sealedSecretsCertURL             *string
+	sealedSecretsServiceNamespace    *string
+	sealedSecretsCertRefreshInterval *time.Duration
+	sealedSecretsCertFile            *string
+	sealedSecretsVerifyURL           *string
+	sealedSecretsReadControllerKeys  *bool
+	impersonateUsers                 *bool
+	enableAuditLog                   *bool
+	metricsPort                      *int
+	enableApply                      *bool
 }
 
 func newFlags() *flags {
@@ -195,6 +457,16 @@ func newFlags() *flags {
 			"Show only successfully synced SealedSecrets in the list (filters out failed/unsynced secrets)",
 		),
 		enableWebLogs: flag.Bool("enable-web-logs", false, "Enable web logs"),
+		enableApply: flag.Bool(
+			"enable-apply",
+			false,
+			"Enable applying sealed secrets to the cluster",
+		),
+		enableAuditLog: flag.Bool(
+			"enable-audit-log",
+			false,
+			"Enable the audit log of secret read and seal operations (written to stdout if no other sink is configured)",
+		),
 		includeNamespaces: flag.String(
 			"include-namespaces",
 			"",
@@ -230,6 +502,31 @@ func newFlags() *flags {
 			"",
 			"URL sealed secrets certificate (required if sealed secrets is not reachable with in cluster service)",
 		),
+		sealedSecretsCertFile: flag.String(
+			"sealed-secrets-cert-file",
+			"",
+			"Path of the sealed secrets certificate file, to seal without access to the cluster. The file is watched for changes.",
+		),
+		sealedSecretsVerifyURL: flag.String(
+			"sealed-secrets-verify-url",
+			"",
+			"URL of the verify endpoint of sealed secrets, to decrypt on validation if sealed secrets is not reachable with in cluster service",
+		),
+		sealedSecretsReadControllerKeys: flag.Bool(
+			"sealed-secrets-read-controller-keys",
+			false,
+			"Read the certificates of the sealed secrets controller keys to inspect sealed secrets (requires list on secrets in the controller namespace)",
+		),
+		sealedSecretsCertRefreshInterval: flag.Duration(
+			"sealed-secrets-cert-refresh-interval",
+			time.Hour,
+			"Interval to refresh the sealed secrets certificate, to pick up rotated keys. (0 disables the refresh)",
+		),
+		impersonateUsers: flag.Bool(
+			"impersonate-users",
+			false,
+			"Load secrets by impersonating the requesting user (from the OIDC login or the X-Forwarded-User header)",
+		),
 		initialSecretFile: flag.String(
 			"initial-secret-file",
 			"",
@@ -247,6 +544,11 @@ func newFlags() *flags {
 			8080,
 			"Define the port to run the application on. (default: 8080)",
 		),
+		metricsPort: flag.Int(
+			"metrics-port",
+			0,
+			"Serve the prometheus metrics on a separate port. (default: 0, metrics are served on the application port)",
+		),
 		config: flag.String("config", "", "Define the config file"),
 	}
 }
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -38,6 +40,11 @@ var _ = Describe("Types", func() {
 				ss.CertURL = "https://cert.url"
 				Ω(ss.String()).Should(Equal("Cert URL: https://cert.url"))
 			})
+			It("should print the cert file", func() {
+				ss.CertURL = "https://cert.url"
+				ss.CertFile = "/cert/cert.pem"
+				Ω(ss.String()).Should(Equal("Cert file: /cert/cert.pem"))
+			})
 			It("should print the service name and namespace", func() {
 				ss.Namespace = "sealed-secrets"
 				ss.Service = "sealed-secrets-svc"
@@ -63,6 +70,34 @@ var _ = Describe("Types", func() {
 			Ω(cfg.SealedSecrets.Namespace).Should(Equal("sealed-secrets"))
 			Ω(cfg.SealedSecrets.Service).Should(Equal("sealed-secrets"))
 		})
+		It("should set the sealedSecretsCertFile", func() {
+			f.sealedSecretsCertFile = new("cert.pem")
+			cfg, err = parseInternal(f)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(cfg.SealedSecrets.CertFile).Should(Equal("cert.pem"))
+			Ω(cfg.SealedSecrets.CanValidate()).Should(BeFalse())
+		})
+		It("should set the sealedSecretsVerifyURL", func() {
+			f.sealedSecretsCertURL = new("cert.url")
+			f.sealedSecretsVerifyURL = new("verify.url")
+			cfg, err = parseInternal(f)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(cfg.SealedSecrets.VerifyURL).Should(Equal("verify.url"))
+			Ω(cfg.SealedSecrets.CanValidate()).Should(BeFalse())
+			Ω(cfg.SealedSecrets.CanVerify()).Should(BeTrue())
+		})
+		It("should not read the controller keys by default", func() {
+			cfg, err = parseInternal(f)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(cfg.SealedSecrets.ReadControllerKeys).Should(BeFalse())
+
+			resetFlagsForTesting()
+			f = newFlags()
+			f.sealedSecretsReadControllerKeys = new(true)
+			cfg, err = parseInternal(f)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(cfg.SealedSecrets.ReadControllerKeys).Should(BeTrue())
+		})
 		It("should set the service namespace and name", func() {
 			f.sealedSecretsServiceName = new("name")
 			f.sealedSecretsServiceNamespace = new("namespace")
@@ -78,6 +113,125 @@ var _ = Describe("Types", func() {
 			Ω(err).ShouldNot(HaveOccurred())
 			Ω(cfg.IncludeNamespaces).Should(ContainElements("foo", "bar"))
 		})
+		It("should refresh the certificate hourly by default", func() {
+			cfg, err = parseInternal(f)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(cfg.SealedSecrets.RefreshInterval()).Should(Equal(time.Hour))
+		})
+		It("should set the certificate refresh interval", func() {
+			f.sealedSecretsCertRefreshInterval = new(5 * time.Minute)
+			cfg, err = parseInternal(f)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(cfg.SealedSecrets.RefreshInterval()).Should(Equal(5 * time.Minute))
+		})
+		It("should use the single sealed secrets config as default target", func() {
+			cfg, err = parseInternal(f)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(cfg.SealingTargets()).Should(HaveLen(1))
+			Ω(cfg.SealingTargets()[0].Name).Should(Equal(DefaultTargetName))
+		})
+		It("should read the sealing targets", func() {
+			f.config = new("../../testdata/config-targets.yaml")
+			cfg, err = parseInternal(f)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			targets := cfg.SealingTargets()
+			Ω(targets).Should(HaveLen(3))
+			Ω(targets[0]).Should(Equal(SealedSecrets{
+				Name:                "dev",
+				Context:             "kind-dev",
+				Service:             "sealed-secrets",
+				Namespace:           "sealed-secrets",
+				CertRefreshInterval: new(30 * time.Minute),
+			}))
+			Ω(targets[1].Name).Should(Equal("prod"))
+			Ω(targets[1].CertURL).Should(Equal("https://sealed-secrets.prod/v1/cert.pem"))
+			Ω(targets[1].RefreshInterval()).Should(Equal(5 * time.Minute))
+			Ω(targets[2].Name).Should(Equal("offline"))
+			Ω(targets[2].RefreshInterval()).Should(BeZero())
+			Ω(cfg.SealedSecrets).Should(Equal(targets[0]))
+		})
+		It("should fail on duplicate target names", func() {
+			cfg := &Config{Targets: []SealedSecrets{{Name: "a"}, {Name: "a"}}}
+			Ω(prepareTargets(cfg)).Should(MatchError("sealing target 'a' is defined more than once"))
+		})
+		It("should fail on targets without name", func() {
+			cfg := &Config{Targets: []SealedSecrets{{Name: "a"}, {}}}
+			Ω(prepareTargets(cfg)).Should(MatchError("sealing target 1 has no name"))
+		})
+		It("should not enable OIDC by default", func() {
+			cfg, err = parseInternal(f)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(cfg.OIDC.Enabled()).Should(BeFalse())
+		})
+		It("should set the OIDC defaults", func() {
+			GinkgoT().Setenv(envOIDCClientSecret, "secret")
+			o := OIDC{IssuerURL: "https://issuer"}
+			prepareOIDC(&o)
+			Ω(o).Should(Equal(OIDC{
+				IssuerURL:     "https://issuer",
+				ClientSecret:  "secret",
+				Scopes:        []string{"openid", "profile", "email"},
+				UsernameClaim: "email",
+				GroupsClaim:   "groups",
+				SessionTTL:    8 * time.Hour,
+			}))
+		})
+		It("should enable impersonation with the default headers", func() {
+			f.impersonateUsers = new(true)
+			cfg, err = parseInternal(f)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(cfg.Impersonation).Should(Equal(Impersonation{
+				Enabled:      true,
+				UserHeader:   "X-Forwarded-User",
+				GroupsHeader: "X-Forwarded-Groups",
+			}))
+		})
+		It("should not set the identity headers if impersonation is disabled", func() {
+			cfg, err = parseInternal(f)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(cfg.Impersonation).Should(Equal(Impersonation{}))
+		})
+		It("should write the audit log to stdout by default", func() {
+			f.enableAuditLog = new(true)
+			cfg, err = parseInternal(f)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(cfg.Audit.Enabled).Should(BeTrue())
+			Ω(cfg.Audit.Stdout).Should(BeTrue())
+		})
+		It("should set the audit sink defaults", func() {
+			a := Audit{Enabled: true, File: AuditFile{Path: "audit.log"}}
+			prepareAudit(&a)
+			Ω(a).Should(Equal(Audit{
+				Enabled: true,
+				File:    AuditFile{Path: "audit.log", MaxSizeMB: 100, MaxBackups: 5},
+				Webhook: AuditWebhook{Timeout: 5 * time.Second},
+			}))
+		})
+		It("should skip the token and helm release secrets in the unmanaged secrets report", func() {
+			cfg, err = parseInternal(f)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(cfg.UnmanagedSecrets.SkipTypes).Should(ConsistOf(
+				"kubernetes.io/service-account-token",
+				"bootstrap.kubernetes.io/token",
+				"helm.sh/release.v1",
+			))
+		})
+		It("should keep the configured skipped secret types", func() {
+			u := UnmanagedSecrets{SkipTypes: []string{}}
+			prepareUnmanagedSecrets(&u)
+			Ω(u.SkipTypes).Should(BeEmpty())
+		})
+		It("should require an identity of the users to unseal", func() {
+			cfg = &Config{Unseal: Unseal{Enabled: true}}
+			Ω(validateUnseal(cfg)).Should(MatchError(ContainSubstring("unsealing requires the OIDC login or impersonation")))
+
+			cfg.Impersonation.Enabled = true
+			Ω(validateUnseal(cfg)).Should(Succeed())
+
+			cfg = &Config{Unseal: Unseal{Enabled: true}, OIDC: OIDC{IssuerURL: "https://issuer"}}
+			Ω(validateUnseal(cfg)).Should(Succeed())
+		})
 		It("should read the initial secrets file", func() {
 			f.initialSecretFile = &testConfigFile
 			cfg, err = parseInternal(f)
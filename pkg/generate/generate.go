@@ -0,0 +1,220 @@
+// Package generate builds typed Kubernetes Secrets from structured input.
+package generate
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Type is the kind of secret to generate.
+type Type string
+
+const (
+	TypeTLS            Type = "tls"
+	TypeDockerRegistry Type = "docker-registry"
+	TypeBasicAuth      Type = "basic-auth"
+	TypeSSHAuth        Type = "ssh-auth"
+
+	// tlsCAKey is the key of the optional CA certificate of a TLS secret.
+	tlsCAKey = "ca.crt"
+)
+
+// Spec is the structured input of a generated secret. Only the section of the type is used.
+type Spec struct {
+	Type        Type              `json:"type"`
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	TLS            *TLS            `json:"tls,omitempty"`
+	DockerRegistry *DockerRegistry `json:"dockerRegistry,omitempty"`
+	BasicAuth      *BasicAuth      `json:"basicAuth,omitempty"`
+	SSHAuth        *SSHAuth        `json:"sshAuth,omitempty"`
+}
+
+// TLS is a certificate with its private key in PEM format.
+type TLS struct {
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+	CA   string `json:"ca,omitempty"`
+}
+
+// DockerRegistry are the credentials of a container registry. Instead of the credentials, an existing
+// docker config json can be given.
+type DockerRegistry struct {
+	Server       string `json:"server,omitempty"`
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+	Email        string `json:"email,omitempty"`
+	DockerConfig string `json:"dockerConfigJSON,omitempty"`
+}
+
+// BasicAuth are the credentials for basic authentication.
+type BasicAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// SSHAuth is a private key in PEM format for SSH authentication.
+type SSHAuth struct {
+	PrivateKey string `json:"privateKey"`
+}
+
+// dockerConfig is the format of the .dockerconfigjson key.
+type dockerConfig struct {
+	Auths map[string]dockerAuth `json:"auths"`
+}
+
+type dockerAuth struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Auth     string `json:"auth,omitempty"`
+}
+
+// Secret validates the spec and generates the typed secret.
+func Secret(spec *Spec) (*corev1.Secret, error) {
+	if spec.Name == "" {
+		return nil, errors.New("the secret must have a name")
+	}
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        spec.Name,
+			Namespace:   spec.Namespace,
+			Labels:      spec.Labels,
+			Annotations: spec.Annotations,
+		},
+	}
+
+	var err error
+	switch spec.Type {
+	case TypeTLS:
+		err = tlsSecret(secret, spec.TLS)
+	case TypeDockerRegistry:
+		err = dockerRegistrySecret(secret, spec.DockerRegistry)
+	case TypeBasicAuth:
+		err = basicAuthSecret(secret, spec.BasicAuth)
+	case TypeSSHAuth:
+		err = sshAuthSecret(secret, spec.SSHAuth)
+	default:
+		err = fmt.Errorf("unsupported secret type '%s'", spec.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func tlsSecret(secret *corev1.Secret, in *TLS) error {
+	if in == nil || in.Cert == "" || in.Key == "" {
+		return errors.New("tls: cert and key are required")
+	}
+	if _, err := tls.X509KeyPair([]byte(in.Cert), []byte(in.Key)); err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+	secret.Type = corev1.SecretTypeTLS
+	secret.StringData = map[string]string{
+		corev1.TLSCertKey:       in.Cert,
+		corev1.TLSPrivateKeyKey: in.Key,
+	}
+	if in.CA != "" {
+		secret.StringData[tlsCAKey] = in.CA
+	}
+	return nil
+}
+
+func dockerRegistrySecret(secret *corev1.Secret, in *DockerRegistry) error {
+	if in == nil {
+		return errors.New("docker-registry: server, username and password or dockerConfigJSON are required")
+	}
+
+	config := in.DockerConfig
+	if config == "" {
+		if in.Server == "" || in.Username == "" || in.Password == "" {
+			return errors.New("docker-registry: server, username and password are required")
+		}
+		b, err := json.Marshal(dockerConfig{Auths: map[string]dockerAuth{
+			in.Server: {
+				Username: in.Username,
+				Password: in.Password,
+				Email:    in.Email,
+				Auth:     base64.StdEncoding.EncodeToString([]byte(in.Username + ":" + in.Password)),
+			},
+		}})
+		if err != nil {
+			return err
+		}
+		config = string(b)
+	} else if err := validateDockerConfig(config); err != nil {
+		return fmt.Errorf("docker-registry: %w", err)
+	}
+
+	secret.Type = corev1.SecretTypeDockerConfigJson
+	secret.StringData = map[string]string{corev1.DockerConfigJsonKey: config}
+	return nil
+}
+
+// validateDockerConfig checks that the docker config json has credentials for each registry.
+func validateDockerConfig(config string) error {
+	var dc dockerConfig
+	if err := json.Unmarshal([]byte(config), &dc); err != nil {
+		return fmt.Errorf("invalid dockerConfigJSON: %w", err)
+	}
+	if len(dc.Auths) == 0 {
+		return errors.New("dockerConfigJSON has no auths")
+	}
+	for server, a := range dc.Auths {
+		if a.Auth == "" {
+			if a.Username == "" || a.Password == "" {
+				return fmt.Errorf("dockerConfigJSON has no credentials for '%s'", server)
+			}
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(a.Auth)
+		if err != nil || !strings.Contains(string(decoded), ":") {
+			return fmt.Errorf("dockerConfigJSON has an invalid auth for '%s'", server)
+		}
+	}
+	return nil
+}
+
+func basicAuthSecret(secret *corev1.Secret, in *BasicAuth) error {
+	if in == nil || (in.Username == "" && in.Password == "") {
+		return errors.New("basic-auth: username or password is required")
+	}
+	secret.Type = corev1.SecretTypeBasicAuth
+	secret.StringData = map[string]string{}
+	if in.Username != "" {
+		secret.StringData[corev1.BasicAuthUsernameKey] = in.Username
+	}
+	if in.Password != "" {
+		secret.StringData[corev1.BasicAuthPasswordKey] = in.Password
+	}
+	return nil
+}
+
+func sshAuthSecret(secret *corev1.Secret, in *SSHAuth) error {
+	if in == nil || in.PrivateKey == "" {
+		return errors.New("ssh-auth: privateKey is required")
+	}
+	if _, err := ssh.ParseRawPrivateKey([]byte(in.PrivateKey)); err != nil {
+		var missing *ssh.PassphraseMissingError
+		if !errors.As(err, &missing) {
+			return fmt.Errorf("ssh-auth: %w", err)
+		}
+	}
+	secret.Type = corev1.SecretTypeSSHAuth
+	secret.StringData = map[string]string{corev1.SSHAuthPrivateKey: in.PrivateKey}
+	return nil
+}
@@ -0,0 +1,156 @@
+package generate
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var cert, key string
+	BeforeEach(func() {
+		c, err := os.ReadFile("../../testdata/cert.pem")
+		Ω(err).ShouldNot(HaveOccurred())
+		k, err := os.ReadFile("../../testdata/key.pem")
+		Ω(err).ShouldNot(HaveOccurred())
+		cert, key = string(c), string(k)
+	})
+
+	It("should require a name", func() {
+		_, err := Secret(&Spec{Type: TypeBasicAuth, BasicAuth: &BasicAuth{Username: "admin"}})
+		Ω(err).Should(MatchError("the secret must have a name"))
+	})
+
+	It("should reject an unknown type", func() {
+		_, err := Secret(&Spec{Type: "opaque", Name: "s"})
+		Ω(err).Should(MatchError("unsupported secret type 'opaque'"))
+	})
+
+	Context("tls", func() {
+		It("should generate a tls secret", func() {
+			secret, err := Secret(&Spec{
+				Type:      TypeTLS,
+				Name:      "web-tls",
+				Namespace: "web",
+				Labels:    map[string]string{"app": "web"},
+				TLS:       &TLS{Cert: cert, Key: key, CA: cert},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(secret.Type).Should(Equal(corev1.SecretTypeTLS))
+			Ω(secret.Name).Should(Equal("web-tls"))
+			Ω(secret.Namespace).Should(Equal("web"))
+			Ω(secret.Labels).Should(Equal(map[string]string{"app": "web"}))
+			Ω(secret.StringData).Should(Equal(map[string]string{"tls.crt": cert, "tls.key": key, "ca.crt": cert}))
+		})
+
+		It("should reject a key that does not match the certificate", func() {
+			other, err := rsa.GenerateKey(rand.Reader, 2048)
+			Ω(err).ShouldNot(HaveOccurred())
+			otherKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(other)})
+
+			_, err = Secret(&Spec{Type: TypeTLS, Name: "web-tls", TLS: &TLS{Cert: cert, Key: string(otherKey)}})
+			Ω(err).Should(MatchError(ContainSubstring("private key does not match public key")))
+		})
+
+		It("should reject an invalid certificate", func() {
+			_, err := Secret(&Spec{Type: TypeTLS, Name: "web-tls", TLS: &TLS{Cert: "foo", Key: key}})
+			Ω(err).Should(MatchError(ContainSubstring("tls:")))
+		})
+	})
+
+	Context("docker-registry", func() {
+		It("should generate the docker config json", func() {
+			secret, err := Secret(&Spec{
+				Type: TypeDockerRegistry,
+				Name: "pull",
+				DockerRegistry: &DockerRegistry{
+					Server:   "registry.example.com",
+					Username: "robot",
+					Password: "s3cret",
+					Email:    "robot@example.com",
+				},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(secret.Type).Should(Equal(corev1.SecretTypeDockerConfigJson))
+
+			var dc dockerConfig
+			Ω(json.Unmarshal([]byte(secret.StringData[".dockerconfigjson"]), &dc)).Should(Succeed())
+			Ω(dc.Auths).Should(Equal(map[string]dockerAuth{
+				"registry.example.com": {
+					Username: "robot",
+					Password: "s3cret",
+					Email:    "robot@example.com",
+					Auth:     base64.StdEncoding.EncodeToString([]byte("robot:s3cret")),
+				},
+			}))
+		})
+
+		It("should accept a well formed docker config json", func() {
+			config := `{"auths":{"ghcr.io":{"auth":"` + base64.StdEncoding.EncodeToString([]byte("u:p")) + `"}}}`
+			secret, err := Secret(&Spec{Type: TypeDockerRegistry, Name: "pull", DockerRegistry: &DockerRegistry{DockerConfig: config}})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(secret.StringData).Should(Equal(map[string]string{".dockerconfigjson": config}))
+		})
+
+		DescribeTable("should reject an invalid docker config json",
+			func(config, msg string) {
+				_, err := Secret(&Spec{Type: TypeDockerRegistry, Name: "pull", DockerRegistry: &DockerRegistry{DockerConfig: config}})
+				Ω(err).Should(MatchError(ContainSubstring(msg)))
+			},
+			Entry("no json", "foo", "invalid dockerConfigJSON"),
+			Entry("no auths", `{"auths":{}}`, "has no auths"),
+			Entry("no credentials", `{"auths":{"ghcr.io":{}}}`, "no credentials for 'ghcr.io'"),
+			Entry("invalid auth", `{"auths":{"ghcr.io":{"auth":"foo"}}}`, "invalid auth for 'ghcr.io'"),
+		)
+
+		It("should require the credentials", func() {
+			_, err := Secret(&Spec{Type: TypeDockerRegistry, Name: "pull", DockerRegistry: &DockerRegistry{Server: "ghcr.io"}})
+			Ω(err).Should(MatchError("docker-registry: server, username and password are required"))
+		})
+	})
+
+	Context("basic-auth", func() {
+		It("should generate a basic-auth secret", func() {
+			secret, err := Secret(&Spec{Type: TypeBasicAuth, Name: "creds", BasicAuth: &BasicAuth{Username: "admin", Password: "pw"}})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(secret.Type).Should(Equal(corev1.SecretTypeBasicAuth))
+			Ω(secret.StringData).Should(Equal(map[string]string{"username": "admin", "password": "pw"}))
+		})
+
+		It("should require a username or password", func() {
+			_, err := Secret(&Spec{Type: TypeBasicAuth, Name: "creds", BasicAuth: &BasicAuth{}})
+			Ω(err).Should(MatchError("basic-auth: username or password is required"))
+		})
+	})
+
+	Context("ssh-auth", func() {
+		It("should generate an ssh-auth secret", func() {
+			_, priv, err := ed25519.GenerateKey(rand.Reader)
+			Ω(err).ShouldNot(HaveOccurred())
+			block, err := ssh.MarshalPrivateKey(priv, "")
+			Ω(err).ShouldNot(HaveOccurred())
+			privateKey := string(pem.EncodeToMemory(block))
+
+			secret, err := Secret(&Spec{Type: TypeSSHAuth, Name: "git", SSHAuth: &SSHAuth{PrivateKey: privateKey}})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(secret.Type).Should(Equal(corev1.SecretTypeSSHAuth))
+			Ω(secret.StringData).Should(Equal(map[string]string{"ssh-privatekey": privateKey}))
+		})
+
+		It("should reject an invalid private key", func() {
+			_, err := Secret(&Spec{Type: TypeSSHAuth, Name: "git", SSHAuth: &SSHAuth{PrivateKey: "foo"}})
+			Ω(err).Should(MatchError(ContainSubstring("ssh-auth:")))
+		})
+	})
+})
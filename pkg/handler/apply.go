@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/bakito/sealed-secrets-web/pkg/audit"
+	"github.com/bakito/sealed-secrets-web/pkg/metrics"
+)
+
+const (
+	queryDryRun          = "dryRun"
+	queryResourceVersion = "resourceVersion"
+)
+
+// Apply results.
+const (
+	applyCreated = "created"
+	applyUpdated = "updated"
+)
+
+// ApplyResult is the response of the apply endpoint.
+type ApplyResult struct {
+	Action    string `json:"action"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	DryRun    bool   `json:"dryRun"`
+}
+
+// alreadyExistsError is returned if the SealedSecret exists and the update is not based on its resource version.
+type alreadyExistsError struct {
+	existing *v1alpha1.SealedSecret
+}
+
+func (e *alreadyExistsError) Error() string {
+	return fmt.Sprintf("sealed secret '%s/%s' already exists, apply it with resourceVersion '%s' to update it",
+		e.existing.Namespace, e.existing.Name, e.existing.ResourceVersion)
+}
+
+// Apply creates or updates the posted SealedSecret in its namespace.
+// With the query parameter dryRun=true, the request is only validated by the api server.
+// An existing SealedSecret is only updated if the resource version of the posted one, or of the query parameter
+// resourceVersion, matches. Otherwise, the existing SealedSecret is returned with 409.
+func (h *SecretsHandler) Apply(c *gin.Context) {
+	defer func() { metrics.ObserveOperation(metrics.OperationApply, "", c.Writer.Status()) }()
+	ev, logAudit := startAudit(h.auditor, h.config, c, audit.OperationApply)
+	defer logAudit()
+
+	if !h.config.EnableApply || h.ssclient == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Applying sealed secrets is disabled"})
+		return
+	}
+
+	sealedSecret, err := readSealedSecret(scheme.Codecs.UniversalDecoder(), c.Request.Body)
+	if err != nil {
+		log.Printf("Error in %s: %s\n", Sanitize(c.FullPath()), Sanitize(err.Error()))
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	ev.Namespace = sealedSecret.Namespace
+	ev.Name = sealedSecret.Name
+	for k := range sealedSecret.Spec.EncryptedData {
+		ev.Keys = append(ev.Keys, k)
+	}
+	slices.Sort(ev.Keys)
+
+	if sealedSecret.Namespace == "" || sealedSecret.Name == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "the sealed secret must have a name and a namespace"})
+		return
+	}
+
+	if err := h.namespaceAllowed(sealedSecret.Namespace); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	rh, status, err := h.forRequest(c)
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if rv := c.Query(queryResourceVersion); rv != "" {
+		sealedSecret.ResourceVersion = rv
+	}
+	dryRun := c.Query(queryDryRun) == "true"
+	action, err := rh.apply(c, sealedSecret, dryRun)
+	var exists *alreadyExistsError
+	if errors.As(err, &exists) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "existing": exists.existing})
+		return
+	}
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(apiErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ApplyResult{
+		Action:    action,
+		Namespace: sealedSecret.Namespace,
+		Name:      sealedSecret.Name,
+		DryRun:    dryRun,
+	})
+}
+
+// apply creates the SealedSecret, or updates it if it already exists in the version the SealedSecret is based on.
+// The labels and annotations of the existing SealedSecret are kept, unless they are overwritten.
+func (h *SecretsHandler) apply(ctx context.Context, sealedSecret *v1alpha1.SealedSecret, dryRun bool) (string, error) {
+	var opts []string
+	if dryRun {
+		opts = []string{metav1.DryRunAll}
+	}
+	client := h.ssclient.SealedSecrets(sealedSecret.Namespace)
+
+	resourceVersion := sealedSecret.ResourceVersion
+	sealedSecret.ResourceVersion = ""
+	_, err := client.Create(ctx, sealedSecret, metav1.CreateOptions{DryRun: opts})
+	if err == nil {
+		return applyCreated, nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return "", err
+	}
+
+	existing, err := client.Get(ctx, sealedSecret.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if resourceVersion == "" {
+		return "", &alreadyExistsError{existing: existing}
+	}
+	if resourceVersion != existing.ResourceVersion {
+		return "", apierrors.NewConflict(
+			v1alpha1.SchemeGroupVersion.WithResource("sealedsecrets").GroupResource(),
+			sealedSecret.Name,
+			fmt.Errorf("the resource version '%s' does not match '%s'", resourceVersion, existing.ResourceVersion),
+		)
+	}
+
+	existing.Labels = mergeStringMaps(existing.Labels, sealedSecret.Labels)
+	existing.Annotations = mergeStringMaps(existing.Annotations, sealedSecret.Annotations)
+	existing.Spec = sealedSecret.Spec
+	// the api server rejects the update if the SealedSecret was changed in the meantime
+	existing.ResourceVersion = resourceVersion
+	if _, err := client.Update(ctx, existing, metav1.UpdateOptions{DryRun: opts}); err != nil {
+		return "", err
+	}
+	return applyUpdated, nil
+}
+
+// mergeStringMaps returns the entries of existing, overwritten by the entries of values.
+func mergeStringMaps(existing, values map[string]string) map[string]string {
+	if len(values) == 0 {
+		return existing
+	}
+	merged := make(map[string]string, len(existing)+len(values))
+	maps.Copy(merged, existing)
+	maps.Copy(merged, values)
+	return merged
+}
+
+// apiErrorStatus maps the api server error to the response status.
+func apiErrorStatus(err error) int {
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Code != 0 {
+		return int(status.Status().Code)
+	}
+	return http.StatusInternalServerError
+}
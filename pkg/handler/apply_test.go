@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	ssv1alpha1 "github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	ssversioned "github.com/bitnami/sealed-secrets/pkg/client/clientset/versioned/fake"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+	"github.com/bakito/sealed-secrets-web/pkg/mocks/ssclient"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Apply", func() {
+	var (
+		recorder *httptest.ResponseRecorder
+		c        *gin.Context
+		cfg      *config.Config
+		client   *ssversioned.Clientset
+		handler  *SecretsHandler
+	)
+	BeforeEach(func() {
+		gin.SetMode(gin.ReleaseMode)
+		recorder = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(recorder)
+		c.Request, _ = http.NewRequest(
+			http.MethodPost,
+			"/api/apply",
+			bytes.NewReader([]byte(existingSealedSecretAsYAML)),
+		)
+		cfg = &config.Config{EnableApply: true}
+		client = ssversioned.NewSimpleClientset()
+	})
+	JustBeforeEach(func() {
+		handler = NewHandler(fake.NewClientset().CoreV1(), client.BitnamiV1alpha1(), cfg)
+	})
+
+	get := func() *ssv1alpha1.SealedSecret {
+		ss, err := client.BitnamiV1alpha1().SealedSecrets("mysecretnamespace").
+			Get(context.Background(), "mysecretname", metav1.GetOptions{})
+		Ω(err).ShouldNot(HaveOccurred())
+		return ss
+	}
+
+	It("should be forbidden if apply is disabled", func() {
+		cfg.EnableApply = false
+		handler = NewHandler(fake.NewClientset().CoreV1(), client.BitnamiV1alpha1(), cfg)
+		handler.Apply(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusForbidden))
+		Ω(recorder.Body.String()).Should(Equal(`{"error":"Applying sealed secrets is disabled"}`))
+	})
+
+	It("should create the sealed secret", func() {
+		handler.Apply(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(Equal(
+			`{"action":"created","namespace":"mysecretnamespace","name":"mysecretname","dryRun":false}`,
+		))
+		Ω(get().Spec.EncryptedData).Should(HaveKeyWithValue("password", "AgBpassword=="))
+	})
+
+	It("should update an existing sealed secret of the resource version", func() {
+		client = ssversioned.NewSimpleClientset(&ssv1alpha1.SealedSecret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "mysecretname",
+				Namespace:       "mysecretnamespace",
+				ResourceVersion: "5",
+				Labels:          map[string]string{"team": "a"},
+			},
+			Spec: ssv1alpha1.SealedSecretSpec{
+				EncryptedData: map[string]string{"username": "AgBusername=="},
+			},
+		})
+		handler = NewHandler(fake.NewClientset().CoreV1(), client.BitnamiV1alpha1(), cfg)
+		c.Request.URL.RawQuery = "resourceVersion=5"
+		handler.Apply(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(ContainSubstring(`"action":"updated"`))
+		Ω(get().Spec.EncryptedData).Should(Equal(ssv1alpha1.SealedSecretEncryptedData{"password": "AgBpassword=="}))
+		Ω(get().Labels).Should(Equal(map[string]string{"team": "a"}))
+	})
+
+	It("should return the existing sealed secret without resource version", func() {
+		client = ssversioned.NewSimpleClientset(&ssv1alpha1.SealedSecret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysecretname", Namespace: "mysecretnamespace", ResourceVersion: "5"},
+			Spec: ssv1alpha1.SealedSecretSpec{
+				EncryptedData: map[string]string{"username": "AgBusername=="},
+			},
+		})
+		handler = NewHandler(fake.NewClientset().CoreV1(), client.BitnamiV1alpha1(), cfg)
+		handler.Apply(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusConflict))
+		Ω(recorder.Body.String()).Should(ContainSubstring("apply it with resourceVersion '5' to update it"))
+		Ω(recorder.Body.String()).Should(ContainSubstring(`"existing":{`))
+		Ω(get().Spec.EncryptedData).Should(Equal(ssv1alpha1.SealedSecretEncryptedData{"username": "AgBusername=="}))
+	})
+
+	It("should reject an outdated resource version", func() {
+		client = ssversioned.NewSimpleClientset(&ssv1alpha1.SealedSecret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysecretname", Namespace: "mysecretnamespace", ResourceVersion: "6"},
+		})
+		handler = NewHandler(fake.NewClientset().CoreV1(), client.BitnamiV1alpha1(), cfg)
+		c.Request.URL.RawQuery = "resourceVersion=5"
+		handler.Apply(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusConflict))
+		Ω(recorder.Body.String()).Should(ContainSubstring("the resource version '5' does not match '6'"))
+	})
+
+	It("should pass dry run to the api server", func() {
+		mock := gomock.NewController(GinkgoT())
+		ssClient := ssclient.NewMockBitnamiV1alpha1Interface(mock)
+		sealedSecrets := ssclient.NewMockSealedSecretInterface(mock)
+		ssClient.EXPECT().SealedSecrets("mysecretnamespace").Return(sealedSecrets)
+		sealedSecrets.EXPECT().
+			Create(gomock.Any(), gomock.Any(), metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}}).
+			Return(&ssv1alpha1.SealedSecret{}, nil)
+
+		handler = NewHandler(fake.NewClientset().CoreV1(), ssClient, cfg)
+		c.Request.URL.RawQuery = "dryRun=true"
+		handler.Apply(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(ContainSubstring(`"dryRun":true`))
+	})
+
+	It("should report conflicts", func() {
+		client = ssversioned.NewSimpleClientset(&ssv1alpha1.SealedSecret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysecretname", Namespace: "mysecretnamespace", ResourceVersion: "5"},
+		})
+		client.PrependReactor("update", "sealedsecrets", func(ktesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewConflict(
+				schema.GroupResource{Group: "bitnami.com", Resource: "sealedsecrets"},
+				"mysecretname",
+				errors.New("the object has been modified"),
+			)
+		})
+		handler = NewHandler(fake.NewClientset().CoreV1(), client.BitnamiV1alpha1(), cfg)
+		c.Request.URL.RawQuery = "resourceVersion=5"
+		handler.Apply(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusConflict))
+		Ω(recorder.Body.String()).Should(ContainSubstring("the object has been modified"))
+	})
+
+	It("should reject not allowed namespaces", func() {
+		cfg.ExcludeNamespaces = []string{"mysecretnamespace"}
+		handler.Apply(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusForbidden))
+		Ω(recorder.Body.String()).Should(Equal(`{"error":"namespace 'mysecretnamespace' is not allowed"}`))
+		_, err := client.BitnamiV1alpha1().SealedSecrets("mysecretnamespace").
+			Get(context.Background(), "mysecretname", metav1.GetOptions{})
+		Ω(apierrors.IsNotFound(err)).Should(BeTrue())
+	})
+
+	It("should reject a sealed secret without namespace", func() {
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/apply", bytes.NewReader([]byte(
+			"apiVersion: bitnami.com/v1alpha1\nkind: SealedSecret\nmetadata:\n  name: foo\n",
+		)))
+		handler.Apply(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+	})
+})
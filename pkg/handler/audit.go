@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/bakito/sealed-secrets-web/pkg/audit"
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+)
+
+// EnableAudit records the seal operations with the given audit logger.
+func (h *Handler) EnableAudit(l *audit.Logger) {
+	h.auditor = l
+}
+
+// EnableAudit records the secret reads with the given audit logger.
+func (h *SecretsHandler) EnableAudit(l *audit.Logger) {
+	h.auditor = l
+}
+
+// startAudit creates the audit event of the request. The returned function logs the event
+// with the outcome of the response and is meant to be deferred. A status set on the event before,
+// e.g. of a failed document of a multi-document request, takes precedence over the response status.
+func startAudit(l *audit.Logger, cfg *config.Config, c *gin.Context, op audit.Operation) (*audit.Event, func()) {
+	e := &audit.Event{
+		ClientIP:  c.ClientIP(),
+		Operation: op,
+	}
+	if l == nil {
+		return e, func() {}
+	}
+
+	if user, ok := trustedIdentity(c, cfg); ok {
+		e.User = user.UserName
+		e.Groups = user.Groups
+	}
+
+	return e, func() {
+		if e.Status == 0 {
+			e.Status = c.Writer.Status()
+		}
+		e.Outcome = audit.OutcomeSuccess
+		if e.Status >= http.StatusBadRequest {
+			e.Outcome = audit.OutcomeFailure
+		}
+		l.Log(e)
+	}
+}
+
+// auditSecret records the name and the key names of the secret.
+func auditSecret(e *audit.Event, sec *corev1.Secret) {
+	e.Namespace = sec.Namespace
+	e.Name = sec.Name
+	e.Keys = e.Keys[:0]
+	for k := range sec.Data {
+		e.Keys = append(e.Keys, k)
+	}
+	for k := range sec.StringData {
+		if _, ok := sec.Data[k]; !ok {
+			e.Keys = append(e.Keys, k)
+		}
+	}
+	slices.Sort(e.Keys)
+}
+
+// auditSecretManifest records the name and the key names of the secret manifest, if it can be parsed.
+func auditSecretManifest(e *audit.Event, body []byte) {
+	if sec, err := parseSecret(body); err == nil {
+		auditSecret(e, sec)
+	}
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/bakito/sealed-secrets-web/pkg/audit"
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+	"github.com/bakito/sealed-secrets-web/pkg/mocks/seal"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Audit", func() {
+	var (
+		recorder *httptest.ResponseRecorder
+		c        *gin.Context
+		buf      *bytes.Buffer
+		auditor  *audit.Logger
+		cfg      *config.Config
+	)
+	BeforeEach(func() {
+		gin.SetMode(gin.ReleaseMode)
+		recorder = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(recorder)
+		buf = &bytes.Buffer{}
+		auditor = audit.NewLogger(audit.NewWriterSink(buf))
+		cfg = &config.Config{Impersonation: config.Impersonation{Enabled: true, UserHeader: "X-Forwarded-User"}}
+	})
+
+	lastEvent := func() *audit.Event {
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		e := &audit.Event{}
+		Ω(json.Unmarshal([]byte(lines[len(lines)-1]), e)).Should(Succeed())
+		return e
+	}
+
+	Context("KubeSeal", func() {
+		var (
+			sealer *seal.MockSealer
+			h      *Handler
+		)
+		BeforeEach(func() {
+			sealer = seal.NewMockSealer(gomock.NewController(GinkgoT()))
+			h = &Handler{sealer: sealer, cfg: cfg}
+			h.EnableAudit(auditor)
+
+			c.Request, _ = http.NewRequest(http.MethodPost, "/v1/kubeseal", bytes.NewReader([]byte(namespaceWideAsYAML)))
+			c.Request.Header.Set("Accept", "application/yaml")
+			c.Request.Header.Set("X-Forwarded-User", "jane")
+		})
+
+		It("should record the sealed secret without values", func() {
+			sealer.EXPECT().Seal("yaml", v1alpha1.NamespaceWideScope, gomock.Any()).Return([]byte(sealedAsYAML), nil)
+
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			e := lastEvent()
+			Ω(e.Operation).Should(Equal(audit.OperationSeal))
+			Ω(e.User).Should(Equal("jane"))
+			Ω(e.Namespace).Should(Equal("mysecretnamespace"))
+			Ω(e.Name).Should(Equal("mysecretname"))
+			Ω(e.Keys).Should(Equal([]string{"username"}))
+			Ω(e.Scope).Should(Equal("namespace-wide"))
+			Ω(e.Outcome).Should(Equal(audit.OutcomeSuccess))
+			Ω(e.Status).Should(Equal(http.StatusOK))
+			Ω(buf.String()).ShouldNot(ContainSubstring("admin"))
+		})
+
+		It("should not trust the identity headers without impersonation", func() {
+			cfg.Impersonation.Enabled = false
+			sealer.EXPECT().Seal("yaml", v1alpha1.NamespaceWideScope, gomock.Any()).Return([]byte(sealedAsYAML), nil)
+
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(lastEvent().User).Should(BeEmpty())
+		})
+
+		It("should record failures", func() {
+			c.Request.URL.RawQuery = "scope=invalid"
+
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+			e := lastEvent()
+			Ω(e.Outcome).Should(Equal(audit.OutcomeFailure))
+			Ω(e.Status).Should(Equal(http.StatusUnprocessableEntity))
+		})
+
+		It("should record the outcome of each document of a multi-document request", func() {
+			invalid := "apiVersion: v1\nkind: Secret\nmetadata:\n  name: c\n  namespace: ns\ndata:\n  password: '%%%'\n"
+			c.Request, _ = http.NewRequest(http.MethodPost, "/v1/kubeseal",
+				bytes.NewReader([]byte(secretA+"---\n"+invalid+"---\n"+secretB)))
+			c.Request.Header.Set("Accept", "application/yaml")
+			sealer.EXPECT().Seal("yaml", v1alpha1.StrictScope, gomock.Any()).Return([]byte(sealedAsYAML), nil)
+			sealer.EXPECT().Seal("yaml", v1alpha1.NamespaceWideScope, gomock.Any()).Return(nil, errors.New("error sealing"))
+
+			h.KubeSeal(c)
+
+			// the documents are recorded in order: the sealed one is not returned, so it fails with the request
+			Ω(recorder.Code).Should(Equal(http.StatusInternalServerError))
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			Ω(lines).Should(HaveLen(3))
+			var statuses []int
+			for _, line := range lines {
+				e := &audit.Event{}
+				Ω(json.Unmarshal([]byte(line), e)).Should(Succeed())
+				Ω(e.Outcome).Should(Equal(audit.OutcomeFailure))
+				statuses = append(statuses, e.Status)
+			}
+			Ω(statuses).Should(Equal([]int{
+				http.StatusInternalServerError,
+				http.StatusUnprocessableEntity,
+				http.StatusInternalServerError,
+			}))
+		})
+	})
+
+	Context("Secret", func() {
+		It("should record the read secret", func() {
+			client := fake.NewClientset(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "ns"},
+				Data:       map[string][]byte{"password": []byte("secret"), "user": []byte("jane")},
+			})
+			h := NewHandler(client.CoreV1(), nil, cfg)
+			h.EnableAudit(auditor)
+
+			c.Request, _ = http.NewRequest(http.MethodGet, "/api/secret/ns/mysecret", http.NoBody)
+			c.Params = gin.Params{{Key: "namespace", Value: "ns"}, {Key: "name", Value: "mysecret"}}
+
+			h.Secret(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			e := lastEvent()
+			Ω(e.Operation).Should(Equal(audit.OperationSecretRead))
+			Ω(e.Namespace).Should(Equal("ns"))
+			Ω(e.Name).Should(Equal("mysecret"))
+			Ω(e.Keys).Should(Equal([]string{"password", "user"}))
+			Ω(e.Outcome).Should(Equal(audit.OutcomeSuccess))
+			Ω(buf.String()).ShouldNot(ContainSubstring("c2VjcmV0"))
+		})
+	})
+})
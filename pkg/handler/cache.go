@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	ssclient "github.com/bitnami/sealed-secrets/pkg/client/clientset/versioned/typed/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// subscriberBuffer is the number of events buffered per subscriber before events are dropped.
+	subscriberBuffer = 100
+	// keepAliveInterval is the interval of the comments sent to keep idle event streams open.
+	keepAliveInterval = 30 * time.Second
+)
+
+// EventType is the kind of change of a SealedSecret.
+type EventType string
+
+const (
+	EventAdded   EventType = "added"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+// SecretEvent is a change of a SealedSecret, including changes of its synced state.
+type SecretEvent struct {
+	Type   EventType `json:"type"`
+	Secret Secret    `json:"secret"`
+}
+
+// SecretCache holds the SealedSecrets of the cluster in shared informers and notifies subscribers about changes.
+type SecretCache struct {
+	informers []cache.SharedIndexInformer
+	stop      context.CancelFunc
+
+	mu          sync.Mutex
+	subscribers map[chan SecretEvent]struct{}
+	onChange    func()
+}
+
+// NewSecretCache creates a cache for the SealedSecrets of the given namespaces. Without namespaces, all
+// namespaces are watched.
+func NewSecretCache(client ssclient.BitnamiV1alpha1Interface, namespaces ...string) *SecretCache {
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	sc := &SecretCache{subscribers: make(map[chan SecretEvent]struct{})}
+	for _, ns := range namespaces {
+		lw := &cache.ListWatch{
+			ListWithContextFunc: func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+				return client.SealedSecrets(ns).List(ctx, opts)
+			},
+			WatchFuncWithContext: func(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+				return client.SealedSecrets(ns).Watch(ctx, opts)
+			},
+		}
+		informer := cache.NewSharedIndexInformer(
+			cache.ToListWatcherWithWatchListSemantics(lw, client),
+			&v1alpha1.SealedSecret{},
+			0,
+			cache.Indexers{},
+		)
+		_, _ = informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj any) {
+				sc.publish(EventAdded, obj)
+			},
+			UpdateFunc: func(oldObj, newObj any) {
+				o, ok1 := oldObj.(*v1alpha1.SealedSecret)
+				n, ok2 := newObj.(*v1alpha1.SealedSecret)
+				if ok1 && ok2 && o.ResourceVersion == n.ResourceVersion {
+					return
+				}
+				sc.publish(EventUpdated, newObj)
+			},
+			DeleteFunc: func(obj any) {
+				if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+					obj = tombstone.Obj
+				}
+				sc.publish(EventDeleted, obj)
+			},
+		})
+		sc.informers = append(sc.informers, informer)
+	}
+	return sc
+}
+
+// Start runs the informers until the context is done and waits for the initial sync.
+// If the informers do not sync within the timeout, they are stopped and an error is returned.
+func (sc *SecretCache) Start(ctx context.Context, timeout time.Duration) error {
+	runCtx, stop := context.WithCancel(ctx)
+	var synced []cache.InformerSynced
+	for _, informer := range sc.informers {
+		go informer.RunWithContext(runCtx)
+		synced = append(synced, informer.HasSynced)
+	}
+
+	syncCtx, cancel := context.WithTimeout(runCtx, timeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), synced...) {
+		stop()
+		return errors.New("timed out waiting for the sealed secrets cache to sync")
+	}
+	sc.stop = stop
+	return nil
+}
+
+// Stop stops the informers.
+func (sc *SecretCache) Stop() {
+	if sc.stop != nil {
+		sc.stop()
+	}
+}
+
+// List returns all cached SealedSecrets.
+func (sc *SecretCache) List() []*v1alpha1.SealedSecret {
+	var list []*v1alpha1.SealedSecret
+	for _, informer := range sc.informers {
+		for _, obj := range informer.GetStore().List() {
+			if ss, ok := obj.(*v1alpha1.SealedSecret); ok {
+				list = append(list, ss)
+			}
+		}
+	}
+	return list
+}
+
+// Get returns the cached SealedSecret, or nil if it is not cached.
+func (sc *SecretCache) Get(namespace, name string) *v1alpha1.SealedSecret {
+	key := namespace + "/" + name
+	for _, informer := range sc.informers {
+		if obj, ok, _ := informer.GetStore().GetByKey(key); ok {
+			if ss, ok := obj.(*v1alpha1.SealedSecret); ok {
+				return ss
+			}
+		}
+	}
+	return nil
+}
+
+// Subscribe registers for the changes of SealedSecrets. The returned function must be called to unsubscribe.
+func (sc *SecretCache) Subscribe() (<-chan SecretEvent, func()) {
+	ch := make(chan SecretEvent, subscriberBuffer)
+	sc.mu.Lock()
+	sc.subscribers[ch] = struct{}{}
+	sc.mu.Unlock()
+
+	return ch, func() {
+		sc.mu.Lock()
+		defer sc.mu.Unlock()
+		if _, ok := sc.subscribers[ch]; ok {
+			delete(sc.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (sc *SecretCache) publish(t EventType, obj any) {
+	ss, ok := obj.(*v1alpha1.SealedSecret)
+	if !ok {
+		return
+	}
+	e := SecretEvent{Type: t, Secret: toSecret(ss)}
+
+	sc.mu.Lock()
+	for ch := range sc.subscribers {
+		select {
+		case ch <- e:
+		default:
+			log.Printf("Dropping sealed secret event for a slow subscriber: %s %s/%s\n", t, ss.Namespace, ss.Name)
+		}
+	}
+	onChange := sc.onChange
+	sc.mu.Unlock()
+
+	if onChange != nil {
+		onChange()
+	}
+}
+
+// notifyChanges registers a function that is called after each change of the cached SealedSecrets.
+func (sc *SecretCache) notifyChanges(f func()) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.onChange = f
+}
+
+// UseCache serves the SealedSecrets from the given cache and enables the live events.
+// The metric of the SealedSecrets by synced state is updated from the whole cache on each change.
+func (h *SecretsHandler) UseCache(sc *SecretCache) {
+	h.cache = sc
+	update := func() { h.updateSyncStates(context.Background()) }
+	sc.notifyChanges(update)
+	update()
+}
+
+// listFromCache returns the cached secrets that match the filter criteria and the label selector.
+func (h *SecretsHandler) listFromCache(selector labels.Selector) []Secret {
+	var items []*v1alpha1.SealedSecret
+	for _, item := range h.cache.List() {
+		if selector.Matches(labels.Set(item.Labels)) {
+			items = append(items, item)
+		}
+	}
+
+	var namespaces []string
+	for _, item := range items {
+		namespaces = append(namespaces, item.Namespace)
+	}
+	allowed := h.allowedNamespaces(namespaces)
+
+	secrets := []Secret{}
+	for _, item := range items {
+		if !allowed(item.Namespace) {
+			continue
+		}
+		secret := toSecret(item)
+		if !h.config.ShowOnlySyncedSecrets || (secret.Synced != nil && *secret.Synced) {
+			secrets = append(secrets, secret)
+		}
+	}
+	slices.SortFunc(secrets, func(i, j Secret) int {
+		if cmp := strings.Compare(i.Namespace, j.Namespace); cmp != 0 {
+			return cmp
+		}
+		return strings.Compare(i.Name, j.Name)
+	})
+	return secrets
+}
+
+// allowedNamespaces returns a function checking the namespace against the include / exclude rules.
+func (h *SecretsHandler) allowedNamespaces(namespaces []string) func(ns string) bool {
+	if len(h.config.ExcludeNamespaces) == 0 && len(h.config.IncludeNamespaces) == 0 {
+		return func(string) bool { return true }
+	}
+	matched := h.NamespacesMatch(namespaces)
+	return func(ns string) bool { return matched[ns] }
+}
+
+// visibleEvent adapts the event to the filter criteria. Secrets of excluded namespaces are not visible,
+// and with ShowOnlySyncedSecrets a secret that is no longer synced is reported as deleted.
+func (h *SecretsHandler) visibleEvent(e SecretEvent) (SecretEvent, bool) {
+	if !h.allowedNamespaces([]string{e.Secret.Namespace})(e.Secret.Namespace) {
+		return e, false
+	}
+	if h.config.ShowOnlySyncedSecrets && e.Type != EventDeleted && (e.Secret.Synced == nil || !*e.Secret.Synced) {
+		if e.Type == EventAdded {
+			return e, false
+		}
+		e.Type = EventDeleted
+	}
+	return e, true
+}
+
+// Events streams the changes of the SealedSecrets as server-sent events.
+func (h *SecretsHandler) Events(c *gin.Context) {
+	if h.disableLoadSecrets {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Loading secrets is disabled"})
+		return
+	}
+	if h.cache == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Live updates of secrets are not available"})
+		return
+	}
+
+	events, unsubscribe := h.cache.Subscribe()
+	defer unsubscribe()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case e, ok := <-events:
+			if !ok {
+				return false
+			}
+			if e, ok = h.visibleEvent(e); ok {
+				c.SSEvent(string(e.Type), e.Secret)
+			}
+			return true
+		}
+	})
+}
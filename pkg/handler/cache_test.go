@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	ssv1alpha1 "github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	ssversioned "github.com/bitnami/sealed-secrets/pkg/client/clientset/versioned/fake"
+	ssclient "github.com/bitnami/sealed-secrets/pkg/client/clientset/versioned/typed/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SecretCache", func() {
+	var (
+		client *ssversioned.Clientset
+		sc     *SecretCache
+		cfg    *config.Config
+		ctx    context.Context
+	)
+	BeforeEach(func() {
+		labeled := sealedSecret("ns1", "secret1", nil)
+		labeled.Labels = map[string]string{"team": "a"}
+		client = ssversioned.NewSimpleClientset(
+			labeled,
+			sealedSecret("ns2", "secret2", new(true)),
+		)
+		cfg = &config.Config{}
+		ctx = context.Background()
+	})
+	JustBeforeEach(func() {
+		sc = NewSecretCache(noWatchListClient{client.BitnamiV1alpha1()})
+		Ω(sc.Start(ctx, 10*time.Second)).Should(Succeed())
+		DeferCleanup(sc.Stop)
+	})
+
+	It("should serve the secrets from the cache", func() {
+		h := NewHandler(fake.NewClientset().CoreV1(), nil, cfg)
+		h.UseCache(sc)
+
+		secrets, err := h.list(ctx)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(secrets).Should(Equal([]Secret{
+			{Namespace: "ns1", Name: "secret1"},
+			{Namespace: "ns2", Name: "secret2", Synced: new(true)},
+		}))
+	})
+
+	It("should apply the filters to the cached secrets", func() {
+		cfg.ExcludeNamespaces = []string{"ns2"}
+		h := NewHandler(fake.NewClientset().CoreV1(), nil, cfg)
+		h.UseCache(sc)
+
+		secrets, err := h.list(ctx)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(secrets).Should(Equal([]Secret{{Namespace: "ns1", Name: "secret1"}}))
+	})
+
+	It("should select the cached secrets by label", func() {
+		h := NewHandler(fake.NewClientset().CoreV1(), nil, cfg)
+		h.UseCache(sc)
+
+		secrets, err := h.listSelected(ctx, labels.SelectorFromSet(labels.Set{"team": "a"}))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(secrets).Should(Equal([]Secret{{Namespace: "ns1", Name: "secret1"}}))
+	})
+
+	It("should count all cached secrets by synced state on each change", func() {
+		h := NewHandler(fake.NewClientset().CoreV1(), nil, cfg)
+		h.UseCache(sc)
+		Ω(gatherSyncStates()).Should(Equal(map[string]float64{"true": 1, "false": 0, "unknown": 1}))
+
+		_, err := h.listSelected(ctx, labels.SelectorFromSet(labels.Set{"team": "a"}))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(gatherSyncStates()).Should(Equal(map[string]float64{"true": 1, "false": 0, "unknown": 1}))
+
+		_, err = client.BitnamiV1alpha1().SealedSecrets("ns3").
+			Create(ctx, sealedSecret("ns3", "secret3", new(false)), metav1.CreateOptions{})
+		Ω(err).ShouldNot(HaveOccurred())
+		Eventually(gatherSyncStates).Should(Equal(map[string]float64{"true": 1, "false": 1, "unknown": 1}))
+	})
+
+	It("should publish the changes", func() {
+		events, unsubscribe := sc.Subscribe()
+		defer unsubscribe()
+
+		ss := sealedSecret("ns3", "secret3", nil)
+		_, err := client.BitnamiV1alpha1().SealedSecrets("ns3").Create(ctx, ss, metav1.CreateOptions{})
+		Ω(err).ShouldNot(HaveOccurred())
+		Eventually(events).Should(Receive(Equal(SecretEvent{
+			Type:   EventAdded,
+			Secret: Secret{Namespace: "ns3", Name: "secret3"},
+		})))
+
+		ss = sealedSecret("ns3", "secret3", new(false))
+		ss.ResourceVersion = "2"
+		_, err = client.BitnamiV1alpha1().SealedSecrets("ns3").Update(ctx, ss, metav1.UpdateOptions{})
+		Ω(err).ShouldNot(HaveOccurred())
+		Eventually(events).Should(Receive(Equal(SecretEvent{
+			Type:   EventUpdated,
+			Secret: Secret{Namespace: "ns3", Name: "secret3", Synced: new(false), Message: "failed"},
+		})))
+
+		Ω(client.BitnamiV1alpha1().SealedSecrets("ns3").Delete(ctx, "secret3", metav1.DeleteOptions{})).Should(Succeed())
+		Eventually(events).Should(Receive(HaveField("Type", EventDeleted)))
+	})
+
+	Context("visibleEvent", func() {
+		var h *SecretsHandler
+		BeforeEach(func() {
+			cfg.ShowOnlySyncedSecrets = true
+			h = NewHandler(nil, nil, cfg)
+		})
+		It("should hide unsynced secrets", func() {
+			_, ok := h.visibleEvent(SecretEvent{Type: EventAdded, Secret: Secret{Namespace: "ns", Name: "a"}})
+			Ω(ok).Should(BeFalse())
+		})
+		It("should report secrets that are no longer synced as deleted", func() {
+			e, ok := h.visibleEvent(SecretEvent{
+				Type:   EventUpdated,
+				Secret: Secret{Namespace: "ns", Name: "a", Synced: new(false)},
+			})
+			Ω(ok).Should(BeTrue())
+			Ω(e.Type).Should(Equal(EventDeleted))
+		})
+	})
+
+	It("should stream the events", func() {
+		h := NewHandler(fake.NewClientset().CoreV1(), nil, cfg)
+		h.UseCache(sc)
+		r := gin.New()
+		r.GET("/api/secrets/events", h.Events)
+		server := httptest.NewServer(r)
+		defer server.Close()
+
+		reqCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(reqCtx, http.MethodGet, server.URL+"/api/secrets/events", http.NoBody)
+		resp, err := http.DefaultClient.Do(req)
+		Ω(err).ShouldNot(HaveOccurred())
+		defer resp.Body.Close()
+		Ω(resp.Header.Get("Content-Type")).Should(Equal("text/event-stream"))
+
+		Eventually(func() int {
+			sc.mu.Lock()
+			defer sc.mu.Unlock()
+			return len(sc.subscribers)
+		}).Should(Equal(1))
+		_, err = client.BitnamiV1alpha1().SealedSecrets("ns3").
+			Create(ctx, sealedSecret("ns3", "secret3", nil), metav1.CreateOptions{})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		reader := bufio.NewReader(resp.Body)
+		var lines []string
+		for len(lines) < 2 {
+			line, err := reader.ReadString('\n')
+			Ω(err).ShouldNot(HaveOccurred())
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		Ω(lines).Should(Equal([]string{
+			"event:added",
+			`data:{"namespace":"ns3","name":"secret3"}`,
+		}))
+	})
+
+	It("should not stream without cache", func() {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/secrets/events", http.NoBody)
+		NewHandler(nil, nil, cfg).Events(c)
+		Ω(recorder.Code).Should(Equal(http.StatusNotImplemented))
+	})
+})
+
+// noWatchListClient marks the fake client as not supporting the watch list semantics, as it does not send bookmarks.
+type noWatchListClient struct {
+	ssclient.BitnamiV1alpha1Interface
+}
+
+func (noWatchListClient) IsWatchListSemanticsUnSupported() bool {
+	return true
+}
+
+// gatherSyncStates returns the metric of the SealedSecrets by synced state.
+func gatherSyncStates() map[string]float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	Ω(err).ShouldNot(HaveOccurred())
+	states := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != "sealed_secrets_web_sealed_secrets" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			states[m.GetLabel()[0].GetValue()] = m.GetGauge().GetValue()
+		}
+	}
+	return states
+}
+
+func sealedSecret(namespace, name string, synced *bool) *ssv1alpha1.SealedSecret {
+	ss := &ssv1alpha1.SealedSecret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, ResourceVersion: "1"},
+	}
+	if synced != nil {
+		status := corev1.ConditionTrue
+		message := ""
+		if !*synced {
+			status = corev1.ConditionFalse
+			message = "failed"
+		}
+		ss.Status = &ssv1alpha1.SealedSecretStatus{Conditions: []ssv1alpha1.SealedSecretCondition{
+			{Type: "Synced", Status: status, Message: message},
+		}}
+	}
+	return ss
+}
@@ -3,16 +3,38 @@ package handler
 import (
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/bakito/sealed-secrets-web/pkg/seal"
+)
+
+const (
+	HeaderCertificateFingerprint = "X-Certificate-Fingerprint"
+	HeaderCertificateNotBefore   = "X-Certificate-Not-Before"
+	HeaderCertificateNotAfter    = "X-Certificate-Not-After"
 )
 
 func (h *Handler) Certificate(c *gin.Context) {
-	certificate, err := h.sealer.Certificate(c)
+	sealer, err := h.sealerFor(c)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	certificate, err := sealer.Certificate(c)
 	if err != nil {
 		log.Printf("Error in reading Certificate %s\n", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+
+	// expose the metadata of the current certificate
+	if info, err := seal.ParseCertificateInfo(certificate); err == nil {
+		c.Header(HeaderCertificateFingerprint, info.Fingerprint)
+		c.Header(HeaderCertificateNotBefore, info.NotBefore.Format(time.RFC3339))
+		c.Header(HeaderCertificateNotAfter, info.NotAfter.Format(time.RFC3339))
+	}
 	c.Data(http.StatusOK, "text/plain; charset=utf-8", certificate)
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/mock/gomock"
@@ -27,6 +28,7 @@ var _ = Describe("Handler ", func() {
 			gin.SetMode(gin.ReleaseMode)
 			recorder = httptest.NewRecorder()
 			c, _ = gin.CreateTestContext(recorder)
+			c.Request, _ = http.NewRequest(http.MethodGet, "/v1/certificate", http.NoBody)
 			mock = gomock.NewController(GinkgoT())
 			sealer = seal.NewMockSealer(mock)
 			h = &Handler{
@@ -41,6 +43,17 @@ var _ = Describe("Handler ", func() {
 			Ω(recorder.Body.String()).Should(Equal(validCertificate))
 			Ω(recorder.Header().Get("Content-Type")).Should(Equal("text/plain; charset=utf-8"))
 		})
+		It("should return the certificate metadata", func() {
+			cert, err := os.ReadFile("../../testdata/cert.pem")
+			Ω(err).ShouldNot(HaveOccurred())
+			sealer.EXPECT().Certificate(gomock.Any()).Return(cert, nil)
+			h.Certificate(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Header().Get(HeaderCertificateFingerprint)).ShouldNot(BeEmpty())
+			Ω(recorder.Header().Get(HeaderCertificateNotBefore)).Should(Equal("2021-08-23T18:53:59Z"))
+			Ω(recorder.Header().Get(HeaderCertificateNotAfter)).Should(Equal("2031-08-21T18:53:59Z"))
+		})
 		It("should successfully fail when requesting a certificate", func() {
 			sealer.EXPECT().Certificate(gomock.Any()).Return(nil, errors.New("unexpected error"))
 			h.Certificate(c)
@@ -67,4 +67,28 @@ type: Opaque
   },
   "type": "Opaque"
 }`
+	namespaceWideAsYAML = `apiVersion: v1
+kind: Secret
+metadata:
+  name: mysecretname
+  namespace: mysecretnamespace
+  annotations:
+    sealedsecrets.bitnami.com/namespace-wide: "true"
+stringData:
+  username: admin
+type: Opaque
+`
+	existingSealedSecretAsYAML = `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: mysecretname
+  namespace: mysecretnamespace
+spec:
+  encryptedData:
+    password: AgBpassword==
+  template:
+    metadata:
+      name: mysecretname
+      namespace: mysecretnamespace
+`
 )
@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
 	"github.com/bitnami/sealed-secrets/pkg/multidocyaml"
 	"github.com/gin-gonic/gin"
 	corev1 "k8s.io/api/core/v1"
@@ -38,7 +39,22 @@ func (h *Handler) Dencode(c *gin.Context) {
 		return
 	}
 
-	encode, err := encodeSecret(h.dencodeInternal(secret), outputFormat)
+	if err := validateSecret(secret); err != nil {
+		contextNegotiate(c, http.StatusUnprocessableEntity, gin.Negotiate{
+			Offered: []string{outputContentType},
+			Data:    errorResponse(err),
+		})
+		return
+	}
+
+	secret, err = filterObject(h.dencodeInternal(secret), h.filter)
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	encode, err := encodeSecret(secret, outputFormat)
 	if err != nil {
 		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -88,3 +104,21 @@ func readSecret(codec runtime.Decoder, r io.Reader) (*corev1.Secret, error) {
 
 	return &ret, nil
 }
+
+func readSealedSecret(codec runtime.Decoder, r io.Reader) (*v1alpha1.SealedSecret, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := multidocyaml.EnsureNotMultiDoc(data); err != nil {
+		return nil, err
+	}
+
+	var ret v1alpha1.SealedSecret
+	if err := runtime.DecodeInto(codec, data, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
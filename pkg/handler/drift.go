@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bakito/sealed-secrets-web/pkg/metrics"
+)
+
+// Drift is the difference between a SealedSecret and the Secret it produces. It contains no secret values.
+type Drift struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Drifted is true if the Secret does not match the SealedSecret.
+	Drifted bool `json:"drifted"`
+	// SecretMissing is true if the Secret does not exist.
+	SecretMissing bool `json:"secretMissing,omitempty"`
+	// MissingKeys are the keys of the SealedSecret that are missing in the Secret.
+	MissingKeys []string `json:"missingKeys,omitempty"`
+	// ExtraKeys are the keys of the Secret that are not in the SealedSecret.
+	ExtraKeys []string `json:"extraKeys,omitempty"`
+	// UnmanagedKeys are the keys of a patched Secret that are not managed by the SealedSecret. They are no drift.
+	UnmanagedKeys []string `json:"unmanagedKeys,omitempty"`
+	// Labels are the labels of the template that differ in the Secret.
+	Labels []string `json:"labels,omitempty"`
+	// Annotations are the annotations of the template that differ in the Secret.
+	Annotations []string `json:"annotations,omitempty"`
+	// Type is the type of the Secret, if it differs from the template.
+	Type *TypeDrift `json:"type,omitempty"`
+	// OwnerReference describes why the Secret is not controlled by the SealedSecret.
+	OwnerReference string `json:"ownerReference,omitempty"`
+}
+
+// TypeDrift is the expected and the actual type of the Secret.
+type TypeDrift struct {
+	Expected corev1.SecretType `json:"expected"`
+	Actual   corev1.SecretType `json:"actual"`
+}
+
+// Drift is an HTTP handler that compares a SealedSecret with the live Secret.
+func (h *SecretsHandler) Drift(c *gin.Context) {
+	if h.disableLoadSecrets {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Loading secrets is disabled"})
+		return
+	}
+
+	namespace := Sanitize(c.Param("namespace"))
+	name := Sanitize(c.Param("name"))
+	if err := h.namespaceAllowed(namespace); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Use the clients of the requesting user, if impersonation is enabled
+	rh, status, err := h.forRequest(c)
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	drift, err := rh.drift(c, namespace, name)
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(apiErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, drift)
+}
+
+// flagDrift sets the drift flag of the secrets. The SealedSecrets and the Secrets are listed once per namespace and
+// compared in memory. Secrets whose drift can not be determined are not flagged.
+func (h *SecretsHandler) flagDrift(ctx context.Context, secrets []Secret) {
+	byNamespace := make(map[string][]*Secret)
+	for i := range secrets {
+		byNamespace[secrets[i].Namespace] = append(byNamespace[secrets[i].Namespace], &secrets[i])
+	}
+	for namespace, nsSecrets := range byNamespace {
+		sealed, live, err := h.namespaceSecrets(ctx, namespace)
+		if err != nil {
+			log.Printf("Error checking drift in namespace %s: %v\n", namespace, err)
+			continue
+		}
+		for _, s := range nsSecrets {
+			ss, ok := sealed[s.Name]
+			if !ok {
+				continue
+			}
+			secret, err := live(s.Name)
+			if err != nil {
+				log.Printf("Error checking drift of %s/%s: %v\n", s.Namespace, s.Name, err)
+				continue
+			}
+			s.Drift = &compareSecret(ss, secret).Drifted
+		}
+	}
+}
+
+// namespaceSecrets returns the SealedSecrets of the namespace by name and a lookup of the live Secrets, nil if
+// a Secret does not exist. The Secrets are listed once. Without the permission to list them, they are read one by one.
+func (h *SecretsHandler) namespaceSecrets(
+	ctx context.Context,
+	namespace string,
+) (map[string]*v1alpha1.SealedSecret, func(name string) (*corev1.Secret, error), error) {
+	sealed := make(map[string]*v1alpha1.SealedSecret)
+	if h.cache != nil {
+		for _, ss := range h.cache.List() {
+			if ss.Namespace == namespace {
+				sealed[ss.Name] = ss
+			}
+		}
+	} else {
+		start := time.Now()
+		ssList, err := h.ssclient.SealedSecrets(namespace).List(ctx, metav1.ListOptions{})
+		metrics.ObserveKubernetesRequest("list_sealedsecrets", time.Since(start))
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range ssList.Items {
+			sealed[ssList.Items[i].Name] = &ssList.Items[i]
+		}
+	}
+
+	start := time.Now()
+	list, err := h.coreClient.Secrets(namespace).List(ctx, metav1.ListOptions{})
+	metrics.ObserveKubernetesRequest("list_secrets", time.Since(start))
+	if apierrors.IsForbidden(err) {
+		return sealed, func(name string) (*corev1.Secret, error) {
+			secret, err := h.liveSecret(ctx, namespace, name)
+			if apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return secret, err
+		}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	live := make(map[string]*corev1.Secret)
+	for i := range list.Items {
+		live[list.Items[i].Name] = &list.Items[i]
+	}
+	return sealed, func(name string) (*corev1.Secret, error) { return live[name], nil }, nil
+}
+
+// drift loads the SealedSecret and its Secret and compares them.
+func (h *SecretsHandler) drift(ctx context.Context, namespace, name string) (*Drift, error) {
+	ss, err := h.sealedSecret(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := h.liveSecret(ctx, namespace, name)
+	if apierrors.IsNotFound(err) {
+		secret = nil
+	} else if err != nil {
+		return nil, err
+	}
+	return compareSecret(ss, secret), nil
+}
+
+// sealedSecret returns the SealedSecret from the cache if available, or else from the cluster.
+func (h *SecretsHandler) sealedSecret(ctx context.Context, namespace, name string) (*v1alpha1.SealedSecret, error) {
+	if h.cache != nil {
+		if ss := h.cache.Get(namespace, name); ss != nil {
+			return ss, nil
+		}
+	}
+	start := time.Now()
+	ss, err := h.ssclient.SealedSecrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	metrics.ObserveKubernetesRequest("get_sealedsecret", time.Since(start))
+	return ss, err
+}
+
+// compareSecret compares the SealedSecret with the Secret the same way the controller produces it.
+// A Secret annotated to be patched may contain keys, labels and annotations not managed by the SealedSecret.
+func compareSecret(ss *v1alpha1.SealedSecret, secret *corev1.Secret) *Drift {
+	d := &Drift{Namespace: ss.Namespace, Name: ss.Name}
+	if secret == nil {
+		d.SecretMissing = true
+		d.MissingKeys = slices.Sorted(maps.Keys(sealedKeys(ss)))
+		d.Drifted = true
+		return d
+	}
+
+	patched := secret.Annotations[v1alpha1.SealedSecretPatchAnnotation] == "true"
+
+	expected := sealedKeys(ss)
+	for k := range expected {
+		if _, ok := secret.Data[k]; !ok {
+			d.MissingKeys = append(d.MissingKeys, k)
+		}
+	}
+	for k := range secret.Data {
+		if _, ok := expected[k]; ok {
+			continue
+		}
+		if patched {
+			d.UnmanagedKeys = append(d.UnmanagedKeys, k)
+		} else {
+			d.ExtraKeys = append(d.ExtraKeys, k)
+		}
+	}
+
+	d.Labels = diffMap(ss.Spec.Template.Labels, secret.Labels, !patched)
+	d.Annotations = diffMap(ss.Spec.Template.Annotations, secret.Annotations, !patched)
+
+	expectedType := ss.Spec.Template.Type
+	if expectedType == "" {
+		expectedType = corev1.SecretTypeOpaque
+	}
+	actualType := secret.Type
+	if actualType == "" {
+		actualType = corev1.SecretTypeOpaque
+	}
+	if expectedType != actualType {
+		d.Type = &TypeDrift{Expected: expectedType, Actual: actualType}
+	}
+
+	if !patched || secret.Annotations[v1alpha1.SealedSecretManagedAnnotation] == "true" {
+		d.OwnerReference = ownerMismatch(ss, secret)
+	}
+
+	slices.Sort(d.MissingKeys)
+	slices.Sort(d.ExtraKeys)
+	slices.Sort(d.UnmanagedKeys)
+	d.Drifted = len(d.MissingKeys) > 0 || len(d.ExtraKeys) > 0 || len(d.Labels) > 0 || len(d.Annotations) > 0 ||
+		d.Type != nil || d.OwnerReference != ""
+	return d
+}
+
+// sealedKeys returns the keys the controller writes into the Secret.
+func sealedKeys(ss *v1alpha1.SealedSecret) map[string]bool {
+	keys := make(map[string]bool)
+	for k := range ss.Spec.EncryptedData {
+		keys[k] = true
+	}
+	for k := range ss.Spec.Template.Data {
+		keys[k] = true
+	}
+	return keys
+}
+
+// diffMap returns the sorted keys whose values differ. With exact, keys only present in the actual map differ as well.
+func diffMap(expected, actual map[string]string, exact bool) []string {
+	var diff []string
+	for k, v := range expected {
+		if a, ok := actual[k]; !ok || a != v {
+			diff = append(diff, k)
+		}
+	}
+	if exact {
+		for k := range actual {
+			if _, ok := expected[k]; !ok {
+				diff = append(diff, k)
+			}
+		}
+	}
+	slices.Sort(diff)
+	return diff
+}
+
+// ownerMismatch describes why the Secret is not controlled by the SealedSecret, or returns an empty string.
+func ownerMismatch(ss *v1alpha1.SealedSecret, secret *corev1.Secret) string {
+	owner := metav1.GetControllerOf(secret)
+	switch {
+	case owner == nil:
+		return "the secret has no controller"
+	case owner.Kind != "SealedSecret" || owner.Name != ss.Name:
+		return fmt.Sprintf("the secret is controlled by %s '%s'", owner.Kind, owner.Name)
+	case ss.UID != "" && owner.UID != ss.UID:
+		return "the secret is controlled by a previous instance of the sealed secret"
+	}
+	return ""
+}
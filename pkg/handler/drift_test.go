@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	ssv1alpha1 "github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	ssversioned "github.com/bitnami/sealed-secrets/pkg/client/clientset/versioned/fake"
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Drift", func() {
+	var (
+		ss     *ssv1alpha1.SealedSecret
+		secret *corev1.Secret
+	)
+	BeforeEach(func() {
+		ss = &ssv1alpha1.SealedSecret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "secret1", UID: types.UID("uid-1")},
+			Spec: ssv1alpha1.SealedSecretSpec{
+				EncryptedData: map[string]string{"password": "AgB...", "username": "AgC..."},
+				Template: ssv1alpha1.SecretTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "a"}},
+					Data:       map[string]string{"url": "https://{{ .username }}@example.com"},
+				},
+			},
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns1",
+				Name:      "secret1",
+				Labels:    map[string]string{"team": "a"},
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(ss, ssv1alpha1.SchemeGroupVersion.WithKind("SealedSecret")),
+				},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{"password": []byte("p"), "username": []byte("u"), "url": []byte("x")},
+		}
+	})
+
+	Context("compareSecret", func() {
+		It("should not report drift of a matching secret", func() {
+			d := compareSecret(ss, secret)
+			Ω(d.Drifted).Should(BeFalse())
+			Ω(d).Should(Equal(&Drift{Namespace: "ns1", Name: "secret1"}))
+		})
+
+		It("should report a missing secret", func() {
+			d := compareSecret(ss, nil)
+			Ω(d.Drifted).Should(BeTrue())
+			Ω(d.SecretMissing).Should(BeTrue())
+			Ω(d.MissingKeys).Should(Equal([]string{"password", "url", "username"}))
+		})
+
+		It("should report missing and extra keys", func() {
+			delete(secret.Data, "password")
+			secret.Data["token"] = []byte("t")
+
+			d := compareSecret(ss, secret)
+			Ω(d.Drifted).Should(BeTrue())
+			Ω(d.MissingKeys).Should(Equal([]string{"password"}))
+			Ω(d.ExtraKeys).Should(Equal([]string{"token"}))
+		})
+
+		It("should report unmanaged keys of a patched secret without drift", func() {
+			secret.Annotations = map[string]string{ssv1alpha1.SealedSecretPatchAnnotation: "true"}
+			secret.OwnerReferences = nil
+			secret.Labels["app"] = "web"
+			secret.Data["token"] = []byte("t")
+
+			d := compareSecret(ss, secret)
+			Ω(d.Drifted).Should(BeFalse())
+			Ω(d.UnmanagedKeys).Should(Equal([]string{"token"}))
+		})
+
+		It("should report edited labels, annotations and type", func() {
+			secret.Labels = map[string]string{"team": "b", "app": "web"}
+			secret.Annotations = map[string]string{"edited": "true"}
+			secret.Type = corev1.SecretTypeBasicAuth
+
+			d := compareSecret(ss, secret)
+			Ω(d.Drifted).Should(BeTrue())
+			Ω(d.Labels).Should(Equal([]string{"app", "team"}))
+			Ω(d.Annotations).Should(Equal([]string{"edited"}))
+			Ω(d.Type).Should(Equal(&TypeDrift{Expected: corev1.SecretTypeOpaque, Actual: corev1.SecretTypeBasicAuth}))
+		})
+
+		DescribeTable("should report owner reference mismatches",
+			func(refs []metav1.OwnerReference, reason string) {
+				secret.OwnerReferences = refs
+				d := compareSecret(ss, secret)
+				Ω(d.Drifted).Should(BeTrue())
+				Ω(d.OwnerReference).Should(ContainSubstring(reason))
+			},
+			Entry("no controller", nil, "no controller"),
+			Entry("other controller", []metav1.OwnerReference{
+				{Kind: "ExternalSecret", Name: "secret1", Controller: new(true)},
+			}, "controlled by ExternalSecret 'secret1'"),
+			Entry("previous instance", []metav1.OwnerReference{
+				{Kind: "SealedSecret", Name: "secret1", UID: "uid-0", Controller: new(true)},
+			}, "previous instance"),
+		)
+	})
+
+	Context("handler", func() {
+		var (
+			recorder *httptest.ResponseRecorder
+			c        *gin.Context
+			cfg      *config.Config
+			core     *fake.Clientset
+			ssClient *ssversioned.Clientset
+		)
+		BeforeEach(func() {
+			gin.SetMode(gin.ReleaseMode)
+			recorder = httptest.NewRecorder()
+			c, _ = gin.CreateTestContext(recorder)
+			c.Params = gin.Params{{Key: "namespace", Value: "ns1"}, {Key: "name", Value: "secret1"}}
+			c.Request, _ = http.NewRequest(http.MethodGet, "/api/drift/ns1/secret1", http.NoBody)
+			cfg = &config.Config{}
+		})
+		JustBeforeEach(func() {
+			core = fake.NewClientset(secret)
+			ssClient = ssversioned.NewSimpleClientset(ss)
+		})
+
+		It("should return the drift without values", func() {
+			secret.Data["token"] = []byte("very-secret")
+			core = fake.NewClientset(secret)
+			NewHandler(core.CoreV1(), ssClient.BitnamiV1alpha1(), cfg).Drift(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Body.String()).ShouldNot(ContainSubstring("very-secret"))
+			var d Drift
+			Ω(json.Unmarshal(recorder.Body.Bytes(), &d)).Should(Succeed())
+			Ω(d.Drifted).Should(BeTrue())
+			Ω(d.ExtraKeys).Should(Equal([]string{"token"}))
+		})
+
+		It("should return not found for an unknown sealed secret", func() {
+			c.Params = gin.Params{{Key: "namespace", Value: "ns1"}, {Key: "name", Value: "unknown"}}
+			NewHandler(core.CoreV1(), ssClient.BitnamiV1alpha1(), cfg).Drift(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusNotFound))
+		})
+
+		It("should reject a namespace that is not allowed", func() {
+			cfg.ExcludeNamespaces = []string{"ns1"}
+			NewHandler(core.CoreV1(), ssClient.BitnamiV1alpha1(), cfg).Drift(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusForbidden))
+		})
+
+		It("should flag the drift in the secret list", func() {
+			c.Request, _ = http.NewRequest(http.MethodGet, "/api/secrets?drift=true", http.NoBody)
+			NewHandler(core.CoreV1(), ssClient.BitnamiV1alpha1(), cfg).AllSecrets(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Body.String()).Should(Equal(
+				`{"secrets":[{"namespace":"ns1","name":"secret1","drift":false}],"total":1}`,
+			))
+		})
+
+		Context("flagDrift", func() {
+			var secrets []Secret
+			BeforeEach(func() {
+				secrets = []Secret{{Namespace: "ns1", Name: "secret1"}, {Namespace: "ns1", Name: "secret2"}}
+			})
+			JustBeforeEach(func() {
+				ss2 := ss.DeepCopy()
+				ss2.Name = "secret2"
+				ssClient = ssversioned.NewSimpleClientset(ss, ss2)
+			})
+
+			It("should list the secrets of a namespace once", func() {
+				NewHandler(core.CoreV1(), ssClient.BitnamiV1alpha1(), cfg).flagDrift(c, secrets)
+
+				Ω(secrets[0].Drift).Should(Equal(new(false)))
+				Ω(secrets[1].Drift).Should(Equal(new(true)))
+				Ω(core.Actions()).Should(HaveLen(1))
+				Ω(core.Actions()[0].GetVerb()).Should(Equal("list"))
+				Ω(ssClient.Actions()).Should(HaveLen(1))
+				Ω(ssClient.Actions()[0].GetVerb()).Should(Equal("list"))
+			})
+
+			It("should read the secrets one by one without the permission to list them", func() {
+				core.PrependReactor("list", "secrets", func(ktesting.Action) (bool, runtime.Object, error) {
+					return true, nil, apierrors.NewForbidden(corev1.Resource("secrets"), "", errors.New("forbidden"))
+				})
+				NewHandler(core.CoreV1(), ssClient.BitnamiV1alpha1(), cfg).flagDrift(c, secrets)
+
+				Ω(secrets[0].Drift).Should(Equal(new(false)))
+				Ω(secrets[1].Drift).Should(Equal(new(true)))
+				Ω(core.Actions()).Should(HaveLen(3))
+			})
+		})
+	})
+})
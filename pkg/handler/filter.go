@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"bytes"
+	"maps"
+	"reflect"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+)
+
+// runtimeFields are the fields set by the api server, that are always removed from loaded secrets.
+var runtimeFields = &config.FieldFilter{
+	Skip: [][]string{
+		{"metadata", "creationTimestamp"},
+		{"metadata", "managedFields"},
+		{"metadata", "ownerReferences"},
+		{"metadata", "resourceVersion"},
+		{"metadata", "uid"},
+	},
+}
+
+// filterObject returns a copy of the object with the fields of the filters removed.
+func filterObject[T any](obj *T, filters ...*config.FieldFilter) (*T, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	for _, ff := range filters {
+		if ff != nil {
+			ff.Apply(u)
+		}
+	}
+	filtered := new(T)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, filtered); err != nil {
+		return nil, err
+	}
+	return filtered, nil
+}
+
+// filterSealedSecret applies the field filter to the encoded sealed secret.
+// The sealed secret is only re-encoded if the filter removes any fields.
+func filterSealedSecret(ff *config.FieldFilter, data []byte, outputFormat string) ([]byte, error) {
+	if ff == nil || len(bytes.TrimSpace(data)) == 0 {
+		return data, nil
+	}
+	var u map[string]any
+	if err := yaml.Unmarshal(data, &u); err != nil {
+		return nil, err
+	}
+	orig := runtime.DeepCopyJSON(maps.Clone(u))
+	ff.Apply(u)
+	if reflect.DeepEqual(orig, u) {
+		return data, nil
+	}
+
+	ss := &v1alpha1.SealedSecret{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, ss); err != nil {
+		return nil, err
+	}
+	return encodeObject(ss, v1alpha1.SchemeGroupVersion, outputFormat)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FieldFilter", func() {
+	var ff *config.FieldFilter
+	BeforeEach(func() {
+		ff = &config.FieldFilter{
+			Skip: [][]string{{"metadata", "annotations", "kubectl.kubernetes.io/*"}},
+			Keep: []config.KeepRule{{Path: []string{"metadata", "annotations", "kubectl.kubernetes.io/keep"}}},
+		}
+	})
+
+	Context("GetSecret", func() {
+		It("should remove the runtime and the configured fields", func() {
+			core := fake.NewClientset(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:         "ns1",
+					Name:              "secret1",
+					UID:               "uid-1",
+					ResourceVersion:   "42",
+					CreationTimestamp: metav1.Now(),
+					ManagedFields:     []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+					OwnerReferences:   []metav1.OwnerReference{{Kind: "SealedSecret", Name: "secret1"}},
+					Annotations: map[string]string{
+						"kubectl.kubernetes.io/last-applied-configuration": "{}",
+						"kubectl.kubernetes.io/keep":                       "yes",
+						"team":                                             "a",
+					},
+				},
+				Data: map[string][]byte{"password": []byte("secret")},
+			})
+			h := NewHandler(core.CoreV1(), nil, &config.Config{FieldFilter: ff})
+
+			secret, err := h.GetSecret(context.Background(), "ns1", "secret1")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(secret).Should(Equal(&corev1.Secret{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "ns1",
+					Name:      "secret1",
+					Annotations: map[string]string{
+						"kubectl.kubernetes.io/keep": "yes",
+						"team":                       "a",
+					},
+				},
+				Data: map[string][]byte{"password": []byte("secret")},
+			}))
+		})
+	})
+
+	Context("filterSealedSecret", func() {
+		It("should return the unchanged sealed secret as it is", func() {
+			data := []byte(sealAsJSON)
+			filtered, err := filterSealedSecret(ff, data, "json")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(filtered).Should(Equal(data))
+		})
+
+		It("should remove the configured fields", func() {
+			filtered, err := filterSealedSecret(ff, []byte(`apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: '{}'
+    team: a
+  name: secret1
+  namespace: ns1
+spec:
+  encryptedData:
+    password: AgB...
+  template:
+    metadata:
+      name: secret1
+      namespace: ns1
+`), "yaml")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(filtered)).Should(Equal(`apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  annotations:
+    team: a
+  name: secret1
+  namespace: ns1
+spec:
+  encryptedData:
+    password: AgB...
+  template:
+    metadata:
+      name: secret1
+      namespace: ns1
+`))
+		})
+	})
+
+	Context("Dencode", func() {
+		It("should remove the configured fields", func() {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/v1/dencode", bytes.NewReader([]byte(`apiVersion: v1
+kind: Secret
+metadata:
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: '{}'
+stringData:
+  username: admin
+type: Opaque
+`)))
+			c.Request.Header.Set("Accept", "application/yaml")
+
+			(&Handler{filter: ff}).Dencode(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Body.String()).Should(Equal(dataAsYAML))
+		})
+	})
+})
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/bakito/sealed-secrets-web/pkg/generate"
+)
+
+const queryGenerate = "generate"
+
+// Generate is an HTTP handler that builds a typed secret from the structured input.
+func (h *Handler) Generate(c *gin.Context) {
+	outputContentType, outputFormat, done := NegotiateFormat(c)
+	if done {
+		return
+	}
+
+	secret, err := generateSecret(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	encode, err := encodeSecret(secret, outputFormat)
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, outputContentType, encode)
+}
+
+// generateSecret reads the generator spec and builds the secret.
+func generateSecret(r io.Reader) (*corev1.Secret, error) {
+	var spec generate.Spec
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("invalid generator input: %w", err)
+	}
+	return generate.Secret(&spec)
+}
+
+// generatedManifest builds the secret manifest from the generator spec in the body.
+func generatedManifest(body []byte) ([]byte, error) {
+	secret, err := generateSecret(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	return encodeSecret(secret, "yaml")
+}
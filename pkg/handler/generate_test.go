@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"github.com/bakito/sealed-secrets-web/pkg/mocks/seal"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	basicAuthSpec = `{
+  "type": "basic-auth",
+  "name": "creds",
+  "namespace": "app",
+  "basicAuth": {"username": "admin", "password": "s3cret"}
+}`
+	basicAuthAsYAML = `apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+  namespace: app
+stringData:
+  password: s3cret
+  username: admin
+type: kubernetes.io/basic-auth
+`
+)
+
+var _ = Describe("Generate", func() {
+	var (
+		recorder *httptest.ResponseRecorder
+		c        *gin.Context
+	)
+	BeforeEach(func() {
+		gin.SetMode(gin.ReleaseMode)
+		recorder = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(recorder)
+	})
+
+	It("should generate the typed secret", func() {
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/generate", bytes.NewReader([]byte(basicAuthSpec)))
+		c.Request.Header.Set("Accept", "application/yaml")
+
+		(&Handler{}).Generate(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(Equal(basicAuthAsYAML))
+	})
+
+	DescribeTable("should reject invalid input",
+		func(body, msg string) {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/generate", bytes.NewReader([]byte(body)))
+			c.Request.Header.Set("Accept", "application/json")
+
+			(&Handler{}).Generate(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+			Ω(recorder.Body.String()).Should(ContainSubstring(msg))
+		},
+		Entry("no json", "foo", "invalid generator input"),
+		Entry("unknown field", `{"type": "tls", "name": "a", "foo": "bar"}`, "unknown field"),
+		Entry("missing input", `{"type": "tls", "name": "a"}`, "tls: cert and key are required"),
+	)
+
+	It("should seal the generated secret", func() {
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/kubeseal?generate=true", bytes.NewReader([]byte(basicAuthSpec)))
+		c.Request.Header.Set("Accept", "application/yaml")
+		sealer := seal.NewMockSealer(gomock.NewController(GinkgoT()))
+		sealer.EXPECT().Seal("yaml", v1alpha1.StrictScope, gomock.Any()).DoAndReturn(
+			func(_ string, _ v1alpha1.SealingScope, r io.Reader) ([]byte, error) {
+				b, err := io.ReadAll(r)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(parseSecret(b)).Should(Equal(mustParseSecret(basicAuthAsYAML)))
+				return []byte(sealedAsYAML), nil
+			})
+
+		(&Handler{sealer: sealer}).KubeSeal(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(Equal(sealedAsYAML))
+	})
+
+	It("should not seal invalid generator input", func() {
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/kubeseal?generate=true", bytes.NewReader([]byte(`{"type": "foo"}`)))
+		c.Request.Header.Set("Accept", "application/json")
+
+		(&Handler{}).KubeSeal(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+	})
+})
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	ssclient "github.com/bitnami/sealed-secrets/pkg/client/clientset/versioned/typed/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	typedv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/bakito/sealed-secrets-web/pkg/auth"
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+)
+
+var errNoIdentity = errors.New("no user identity to impersonate")
+
+// ClientFactory creates the Kubernetes clients acting as the given user.
+type ClientFactory func(
+	impersonate rest.ImpersonationConfig,
+) (typedv1.CoreV1Interface, ssclient.BitnamiV1alpha1Interface, error)
+
+// ImpersonatingClients returns a ClientFactory building per request clients from the given configuration.
+func ImpersonatingClients(clientConfig clientcmd.ClientConfig) ClientFactory {
+	return func(impersonate rest.ImpersonationConfig) (typedv1.CoreV1Interface, ssclient.BitnamiV1alpha1Interface, error) {
+		return BuildClients(clientConfig, false, &impersonate)
+	}
+}
+
+// Impersonate enables loading the secrets with the identity of the requesting user,
+// so the cluster RBAC decides which secrets are visible.
+func (h *SecretsHandler) Impersonate(factory ClientFactory) {
+	h.impersonatedClients = factory
+}
+
+// forRequest returns the handler to be used for the request. If impersonation is enabled,
+// the returned handler uses clients acting as the requesting user.
+func (h *SecretsHandler) forRequest(c *gin.Context) (*SecretsHandler, int, error) {
+	if h.impersonatedClients == nil {
+		return h, http.StatusOK, nil
+	}
+
+	impersonate, ok := identity(c, h.config.Impersonation)
+	if !ok {
+		return nil, http.StatusUnauthorized, errNoIdentity
+	}
+
+	coreClient, ssCl, err := h.impersonatedClients(*impersonate)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	rh := *h
+	rh.coreClient = coreClient
+	rh.ssclient = ssCl
+	rh.impersonate = impersonate
+	rh.cache = nil // the cache holds the secrets visible to the service account
+	return &rh, http.StatusOK, nil
+}
+
+// identity returns the user of the request, either from the login session or from the trusted headers.
+func identity(c *gin.Context, cfg config.Impersonation) (*rest.ImpersonationConfig, bool) {
+	if user, ok := auth.UserFrom(c); ok && user.Name != "" {
+		return &rest.ImpersonationConfig{UserName: user.Name, Groups: user.Groups}, true
+	}
+	if cfg.UserHeader == "" {
+		return nil, false
+	}
+
+	name := strings.TrimSpace(c.GetHeader(cfg.UserHeader))
+	if name == "" {
+		return nil, false
+	}
+
+	var groups []string
+	for _, value := range c.Request.Header.Values(cfg.GroupsHeader) {
+		for g := range strings.SplitSeq(value, ",") {
+			if g = strings.TrimSpace(g); g != "" {
+				groups = append(groups, g)
+			}
+		}
+	}
+	return &rest.ImpersonationConfig{UserName: name, Groups: groups}, true
+}
+
+// trustedIdentity returns the user of the request. The identity headers are only trusted with impersonation
+// enabled, behind an authenticating proxy. Otherwise, only the logged in user is returned.
+func trustedIdentity(c *gin.Context, cfg *config.Config) (*rest.ImpersonationConfig, bool) {
+	var impersonation config.Impersonation
+	if cfg != nil && cfg.Impersonation.Enabled {
+		impersonation = cfg.Impersonation
+	}
+	return identity(c, impersonation)
+}
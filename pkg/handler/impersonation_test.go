@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	ssv1alpha1 "github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	ssclient "github.com/bitnami/sealed-secrets/pkg/client/clientset/versioned/typed/sealedsecrets/v1alpha1"
+	ssfake "github.com/bitnami/sealed-secrets/pkg/client/clientset/versioned/typed/sealedsecrets/v1alpha1/fake"
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	typedv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	ktesting "k8s.io/client-go/testing"
+
+	"github.com/bakito/sealed-secrets-web/pkg/auth"
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Impersonation", func() {
+	var (
+		recorder     *httptest.ResponseRecorder
+		c            *gin.Context
+		handler      *SecretsHandler
+		userSSClient *ssfake.FakeBitnamiV1alpha1
+		impersonated *rest.ImpersonationConfig
+		factoryErr   error
+	)
+	BeforeEach(func() {
+		gin.SetMode(gin.ReleaseMode)
+		recorder = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(recorder)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/secrets", http.NoBody)
+
+		serviceAccountSSClient := &ssfake.FakeBitnamiV1alpha1{Fake: &ktesting.Fake{}}
+		setupSealedSecretsReactor(serviceAccountSSClient, []ssv1alpha1.SealedSecret{
+			{ObjectMeta: metav1.ObjectMeta{Name: "secret1", Namespace: "ns1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "secret2", Namespace: "ns2"}},
+		})
+		userSSClient = &ssfake.FakeBitnamiV1alpha1{Fake: &ktesting.Fake{}}
+		setupSealedSecretsReactor(userSSClient, []ssv1alpha1.SealedSecret{
+			{ObjectMeta: metav1.ObjectMeta{Name: "secret1", Namespace: "ns1"}},
+		})
+
+		cfg := &config.Config{Impersonation: config.Impersonation{
+			Enabled:      true,
+			UserHeader:   "X-Forwarded-User",
+			GroupsHeader: "X-Forwarded-Groups",
+		}}
+		handler = NewHandler(fake.NewClientset().CoreV1(), serviceAccountSSClient, cfg)
+
+		impersonated = nil
+		factoryErr = nil
+		handler.Impersonate(func(
+			impersonate rest.ImpersonationConfig,
+		) (typedv1.CoreV1Interface, ssclient.BitnamiV1alpha1Interface, error) {
+			impersonated = &impersonate
+			return fake.NewClientset().CoreV1(), userSSClient, factoryErr
+		})
+	})
+
+	It("should reject requests without identity", func() {
+		handler.AllSecrets(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusUnauthorized))
+		Ω(recorder.Body.String()).Should(Equal(`{"error":"no user identity to impersonate"}`))
+		Ω(impersonated).Should(BeNil())
+	})
+
+	It("should list the secrets as the user of the forwarded headers", func() {
+		c.Request.Header.Set("X-Forwarded-User", "jane")
+		c.Request.Header.Add("X-Forwarded-Groups", "devs, ops")
+		c.Request.Header.Add("X-Forwarded-Groups", "admins")
+		handler.AllSecrets(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(Equal(`{"secrets":[{"namespace":"ns1","name":"secret1"}],"total":1}`))
+		Ω(impersonated).Should(Equal(&rest.ImpersonationConfig{
+			UserName: "jane",
+			Groups:   []string{"devs", "ops", "admins"},
+		}))
+	})
+
+	It("should prefer the logged in user over the headers", func() {
+		c.Request.Header.Set("X-Forwarded-User", "mallory")
+		auth.SetUser(c, &auth.User{Name: "jane@example.com", Groups: []string{"devs"}})
+		handler.AllSecrets(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(impersonated).Should(Equal(&rest.ImpersonationConfig{
+			UserName: "jane@example.com",
+			Groups:   []string{"devs"},
+		}))
+	})
+
+	It("should return an error if the clients can not be created", func() {
+		c.Request.Header.Set("X-Forwarded-User", "jane")
+		factoryErr = errors.New("boom")
+		handler.AllSecrets(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusInternalServerError))
+		Ω(recorder.Body.String()).Should(Equal(`{"error":"boom"}`))
+	})
+
+	It("should reject loading a single secret without identity", func() {
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/secret/ns1/secret1", http.NoBody)
+		handler.Secret(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusUnauthorized))
+	})
+
+	It("should use the service account clients if impersonation is disabled", func() {
+		handler.Impersonate(nil)
+		handler.AllSecrets(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(ContainSubstring("secret2"))
+	})
+})
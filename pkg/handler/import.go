@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/bakito/sealed-secrets-web/pkg/importer"
+)
+
+const (
+	queryImport     = "import"
+	queryFormat     = "format"
+	queryName       = "name"
+	queryNamespace  = "namespace"
+	querySecretType = "type"
+)
+
+// Import is an HTTP handler that builds a secret from the key value pairs of a .env, properties, json or yaml file.
+// The format, name, namespace and type of the secret are given as query parameters.
+func (h *Handler) Import(c *gin.Context) {
+	outputContentType, outputFormat, done := NegotiateFormat(c)
+	if done {
+		return
+	}
+
+	secret, err := importSecret(c, c.Request.Body)
+	if err == nil {
+		err = validateSecret(secret)
+	}
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	encode, err := encodeSecret(secret, outputFormat)
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, outputContentType, encode)
+}
+
+// importSecret reads the imported file and builds the secret described by the query parameters.
+func importSecret(c *gin.Context, r io.Reader) (*corev1.Secret, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return importer.Secret(&importer.Spec{
+		Format:    importer.Format(c.Query(queryFormat)),
+		Name:      c.Query(queryName),
+		Namespace: c.Query(queryNamespace),
+		Type:      corev1.SecretType(c.Query(querySecretType)),
+	}, data)
+}
+
+// importedManifest builds the secret manifest from the imported file in the body.
+func importedManifest(c *gin.Context, body []byte) ([]byte, error) {
+	secret, err := importSecret(c, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	return encodeSecret(secret, "yaml")
+}
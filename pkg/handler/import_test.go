@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"github.com/bakito/sealed-secrets-web/pkg/mocks/seal"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	dotEnv = `export DB_USER=admin
+DB_PASSWORD="s3cret # not a comment"
+`
+	dotEnvAsYAML = `apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+  namespace: app
+stringData:
+  DB_PASSWORD: 's3cret # not a comment'
+  DB_USER: admin
+type: Opaque
+`
+)
+
+var _ = Describe("Import", func() {
+	var (
+		recorder *httptest.ResponseRecorder
+		c        *gin.Context
+	)
+	BeforeEach(func() {
+		gin.SetMode(gin.ReleaseMode)
+		recorder = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(recorder)
+	})
+
+	It("should import the secret", func() {
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/import?format=env&name=db&namespace=app",
+			bytes.NewReader([]byte(dotEnv)))
+		c.Request.Header.Set("Accept", "application/yaml")
+
+		(&Handler{}).Import(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(Equal(dotEnvAsYAML))
+	})
+
+	It("should report duplicate keys", func() {
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/import?format=env&name=db",
+			bytes.NewReader([]byte("A=1\nA=2\n")))
+		c.Request.Header.Set("Accept", "application/json")
+
+		(&Handler{}).Import(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+		Ω(recorder.Body.String()).Should(Equal(`{"error":"invalid env input: duplicate key 'A' on lines 1 and 2"}`))
+	})
+
+	It("should validate the imported secret", func() {
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/import?format=json&name=db",
+			bytes.NewReader([]byte(`{"my key": "value"}`)))
+		c.Request.Header.Set("Accept", "application/json")
+
+		(&Handler{}).Import(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+		Ω(recorder.Body.String()).Should(ContainSubstring(`"field":"stringData[my key]"`))
+	})
+
+	It("should seal the imported secret", func() {
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/kubeseal?import=true&format=env&name=db&namespace=app",
+			bytes.NewReader([]byte(dotEnv)))
+		c.Request.Header.Set("Accept", "application/yaml")
+		sealer := seal.NewMockSealer(gomock.NewController(GinkgoT()))
+		sealer.EXPECT().Seal("yaml", v1alpha1.StrictScope, gomock.Any()).DoAndReturn(
+			func(_ string, _ v1alpha1.SealingScope, r io.Reader) ([]byte, error) {
+				b, err := io.ReadAll(r)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(parseSecret(b)).Should(Equal(mustParseSecret(dotEnvAsYAML)))
+				return []byte(sealedAsYAML), nil
+			})
+
+		(&Handler{sealer: sealer}).KubeSeal(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(Equal(sealedAsYAML))
+	})
+
+	It("should not seal invalid imported input", func() {
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/kubeseal?import=true&format=xml&name=db",
+			bytes.NewReader([]byte("foo")))
+		c.Request.Header.Set("Accept", "application/json")
+
+		(&Handler{}).KubeSeal(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+	})
+})
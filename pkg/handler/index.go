@@ -5,21 +5,27 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/bakito/sealed-secrets-web/pkg/audit"
 	"github.com/bakito/sealed-secrets-web/pkg/config"
+	"github.com/bakito/sealed-secrets-web/pkg/policy"
 	"github.com/bakito/sealed-secrets-web/pkg/seal"
 	"github.com/bakito/sealed-secrets-web/pkg/version"
 )
 
 type Handler struct {
 	sealer    seal.Sealer
+	registry  *seal.Registry
 	indexHTML string
 	filter    *config.FieldFilter
 	cfg       *config.Config
+	auditor   *audit.Logger
+	policy    *policy.Policy
 }
 
-func New(indexHTML string, sealer seal.Sealer, cfg *config.Config) *Handler {
+func New(indexHTML string, registry *seal.Registry, cfg *config.Config) *Handler {
 	return &Handler{
-		sealer:    sealer,
+		sealer:    registry.Default(),
+		registry:  registry,
 		indexHTML: indexHTML,
 		cfg:       cfg,
 		filter:    cfg.FieldFilter,
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/bakito/sealed-secrets-web/pkg/seal"
+)
+
+// Inspection tells which of the known certificates the values of a SealedSecret can be sealed with.
+type Inspection struct {
+	Namespace    string                  `json:"namespace"`
+	Name         string                  `json:"name"`
+	Scope        string                  `json:"scope"`
+	Certificates []seal.KnownCertificate `json:"certificates"`
+	Values       []seal.ValueInspection  `json:"values"`
+}
+
+// Inspect checks the encrypted values of a SealedSecret against the known certificates of the sealing target.
+func (h *Handler) Inspect(c *gin.Context) {
+	sealer, err := h.sealerFor(c)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		log.Printf("Error reading body in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	sealedSecret, err := readSealedSecret(scheme.Codecs.UniversalDecoder(), bytes.NewReader(body))
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	certs, err := sealer.KnownCertificates(c)
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// the private keys decrypt the values, so they are only used for the users allowed to unseal.
+	// Otherwise, the candidate keys are identified by the certificates only.
+	keys := seal.PrivateKeys{}
+	if _, err := h.unsealAllowed(c); err == nil {
+		if keys, err = privateKeys(h.cfg); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	scope := sealedSecret.Scope()
+	c.JSON(http.StatusOK, Inspection{
+		Namespace:    sealedSecret.Namespace,
+		Name:         sealedSecret.Name,
+		Scope:        scope.String(),
+		Certificates: certs,
+		Values:       seal.Inspect(sealedSecret, certs, keys),
+	})
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/bitnami/sealed-secrets/pkg/kubeseal"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/bakito/sealed-secrets-web/pkg/auth"
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+	"github.com/bakito/sealed-secrets-web/pkg/mocks/seal"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Inspect", func() {
+	const sealedSecret = `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: mysecret
+  namespace: mynamespace
+spec:
+  encryptedData:
+    password: AgB=
+  template:
+    metadata:
+      annotations:
+        sealedsecrets.bitnami.com/namespace-wide: "true"
+`
+	var (
+		recorder *httptest.ResponseRecorder
+		c        *gin.Context
+		sealer   *seal.MockSealer
+		h        *Handler
+	)
+	BeforeEach(func() {
+		gin.SetMode(gin.ReleaseMode)
+		recorder = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(recorder)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/inspect", strings.NewReader(sealedSecret))
+		sealer = seal.NewMockSealer(gomock.NewController(GinkgoT()))
+		h = &Handler{sealer: sealer}
+	})
+
+	It("should inspect the encrypted values", func() {
+		sealer.EXPECT().KnownCertificates(gomock.Any()).Return(nil, nil)
+		h.Inspect(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(Equal(`{"namespace":"mynamespace","name":"mysecret","scope":"namespace-wide",` +
+			`"certificates":null,"values":[{"key":"password","error":"truncated: 2 bytes, expected at least 530",` +
+			`"candidates":[]}]}`))
+	})
+
+	It("should take the scope from the template", func() {
+		const outer = `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: mysecret
+  namespace: mynamespace
+  annotations:
+    sealedsecrets.bitnami.com/cluster-wide: "true"
+spec:
+  encryptedData:
+    password: AgB=
+`
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/inspect", strings.NewReader(outer))
+		sealer.EXPECT().KnownCertificates(gomock.Any()).Return(nil, nil)
+		h.Inspect(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(ContainSubstring(`"scope":"strict"`))
+	})
+
+	It("should fail if the certificates can not be read", func() {
+		sealer.EXPECT().KnownCertificates(gomock.Any()).Return(nil, errors.New("boom"))
+		h.Inspect(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusInternalServerError))
+		Ω(recorder.Body.String()).Should(Equal(`{"error":"boom"}`))
+	})
+
+	Context("private keys", func() {
+		BeforeEach(func() {
+			f, err := os.Open("../../testdata/cert.pem")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer func() { _ = f.Close() }()
+			pubKey, err := kubeseal.ParseKey(f)
+			Ω(err).ShouldNot(HaveOccurred())
+			ss, err := v1alpha1.NewSealedSecret(scheme.Codecs, pubKey, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "mynamespace"},
+				Data:       map[string][]byte{"password": []byte("s3cret")},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			body, err := json.Marshal(ss)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			key, err := os.ReadFile("../../testdata/key.pem")
+			Ω(err).ShouldNot(HaveOccurred())
+			keyDir := GinkgoT().TempDir()
+			Ω(os.WriteFile(filepath.Join(keyDir, "key.pem"), key, 0o600)).Should(Succeed())
+
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/inspect", bytes.NewReader(body))
+			h.cfg = &config.Config{Unseal: config.Unseal{Enabled: true, KeysDir: keyDir, Groups: []string{"recovery"}}}
+			sealer.EXPECT().KnownCertificates(gomock.Any()).Return(nil, nil)
+		})
+
+		It("should identify the key for the users allowed to unseal", func() {
+			auth.SetUser(c, &auth.User{Name: "jane@example.com", Groups: []string{"recovery"}})
+			h.Inspect(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Body.String()).Should(ContainSubstring(`"sealedWith":`))
+		})
+
+		It("should not use the private keys for other users", func() {
+			auth.SetUser(c, &auth.User{Name: "joe@example.com", Groups: []string{"dev"}})
+			h.Inspect(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Body.String()).ShouldNot(ContainSubstring(`"sealedWith":`))
+		})
+
+		It("should not use the private keys for unknown users", func() {
+			h.Inspect(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Body.String()).ShouldNot(ContainSubstring(`"sealedWith":`))
+		})
+	})
+
+	It("should reject an invalid manifest", func() {
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/inspect", strings.NewReader("foo"))
+		h.Inspect(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+	})
+})
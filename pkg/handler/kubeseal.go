@@ -4,17 +4,32 @@ import (
 	"bytes"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"slices"
 
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/yaml"
+
+	"github.com/bakito/sealed-secrets-web/pkg/audit"
+	"github.com/bakito/sealed-secrets-web/pkg/metrics"
+	"github.com/bakito/sealed-secrets-web/pkg/policy"
+	"github.com/bakito/sealed-secrets-web/pkg/seal"
 )
 
-const errInvalidBase64 = "data must be uniformly base64-encoded or in plain text, not mixed up. Use .data for encoded or .stringData for plaintext"
+const (
+	errInvalidBase64 = "data must be uniformly base64-encoded or in plain text, not mixed up. Use .data for encoded or .stringData for plaintext"
+
+	// HeaderSealingScope is used to request a sealing scope and to report the scope that was used.
+	HeaderSealingScope = "X-Sealing-Scope"
+	queryScope         = "scope"
+)
 
 func (h *Handler) KubeSeal(c *gin.Context) {
 	outputContentType, outputFormat, done := NegotiateFormat(c)
@@ -22,6 +37,17 @@ func (h *Handler) KubeSeal(c *gin.Context) {
 		return
 	}
 
+	defer h.observe(c, metrics.OperationSeal)
+
+	output, err := outputMode(c, outputContentType)
+	if err != nil {
+		contextNegotiate(c, http.StatusBadRequest, gin.Negotiate{
+			Offered: []string{outputContentType},
+			Data:    gin.H{"error": err.Error()},
+		})
+		return
+	}
+
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
 		log.Printf("Error reading body in %s: %v\n", Sanitize(c.FullPath()), err)
@@ -32,26 +58,182 @@ func (h *Handler) KubeSeal(c *gin.Context) {
 		return
 	}
 
-	if err := validateBase64Data(body); err != nil {
-		contextNegotiate(c, http.StatusUnprocessableEntity, gin.Negotiate{
+	// build the secret from the structured generator input
+	if c.Query(queryGenerate) == "true" {
+		if body, err = generatedManifest(body); err != nil {
+			contextNegotiate(c, http.StatusUnprocessableEntity, gin.Negotiate{
+				Offered: []string{outputContentType},
+				Data:    gin.H{"error": err.Error()},
+			})
+			return
+		}
+	}
+
+	// build the secret from the imported key value pairs
+	if c.Query(queryImport) == "true" {
+		if body, err = importedManifest(c, body); err != nil {
+			contextNegotiate(c, http.StatusUnprocessableEntity, gin.Negotiate{
+				Offered: []string{outputContentType},
+				Data:    gin.H{"error": err.Error()},
+			})
+			return
+		}
+	}
+
+	sealer, err := h.sealerFor(c)
+	if err != nil {
+		contextNegotiate(c, http.StatusNotFound, gin.Negotiate{
 			Offered: []string{outputContentType},
 			Data:    gin.H{"error": err.Error()},
 		})
 		return
 	}
 
-	ss, err := h.sealer.Seal(outputFormat, bytes.NewReader(body))
+	// multiple documents or a List are sealed one by one
+	docs, isList, err := splitDocuments(body)
+	if err == nil && (len(docs) > 1 || isList) {
+		if output != OutputManifest {
+			contextNegotiate(c, http.StatusBadRequest, gin.Negotiate{
+				Offered: []string{outputContentType},
+				Data:    gin.H{"error": fmt.Sprintf("output '%s' supports a single secret only", output)},
+			})
+			return
+		}
+		h.kubeSealDocuments(c, sealer, docs, isList, outputContentType, outputFormat)
+		return
+	}
+
+	ev, logAudit := startAudit(h.auditor, h.cfg, c, audit.OperationSeal)
+	defer logAudit()
+	ev.Target = h.selectedTarget(c)
+	auditSecretManifest(ev, body)
+
+	ss, scope, status, err := h.sealDocument(c, sealer, body, outputFormat)
+	ev.Scope = scope.String()
+	if err != nil {
+		if status == http.StatusInternalServerError {
+			log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		}
+		contextNegotiate(c, status, gin.Negotiate{
+			Offered: []string{outputContentType},
+			Data:    errorResponse(err),
+		})
+		return
+	}
+
+	out, err := renderOutput(c, output, ss, outputFormat)
+	if err != nil {
+		contextNegotiate(c, http.StatusBadRequest, gin.Negotiate{
+			Offered: []string{outputContentType},
+			Data:    gin.H{"error": err.Error()},
+		})
+		return
+	}
+
+	c.Header(HeaderSealingScope, scope.String())
+	c.Data(http.StatusOK, outputContentType, out)
+}
+
+// kubeSealDocuments seals multiple documents and responds with the sealed secrets in the same order.
+// If any document can not be sealed, the errors of all failed documents are returned.
+func (h *Handler) kubeSealDocuments(
+	c *gin.Context,
+	sealer seal.Sealer,
+	docs [][]byte,
+	isList bool,
+	outputContentType, outputFormat string,
+) {
+	sealed, scopes, status, docErrs, logAudit := h.sealDocuments(c, sealer, docs, outputFormat)
+	defer logAudit()
+	if len(docErrs) > 0 {
+		log.Printf("Error in %s: %d of %d documents could not be sealed\n", Sanitize(c.FullPath()), len(docErrs), len(docs))
+		contextNegotiate(c, status, gin.Negotiate{
+			Offered: []string{outputContentType},
+			Data: gin.H{
+				"error":     fmt.Sprintf("%d of %d documents could not be sealed", len(docErrs), len(docs)),
+				"documents": docErrs,
+			},
+		})
+		return
+	}
+
+	out, err := joinSealed(sealed, isList, outputFormat)
 	if err != nil {
 		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
 		contextNegotiate(c, http.StatusInternalServerError, gin.Negotiate{
 			Offered: []string{outputContentType},
 			Data:    gin.H{"error": err.Error()},
 		})
-		c.Data(http.StatusInternalServerError, outputContentType, ss)
 		return
 	}
 
-	c.Data(http.StatusOK, outputContentType, ss)
+	// the scope is only reported if all documents were sealed with the same scope
+	if len(scopes) > 0 && !slices.ContainsFunc(scopes, func(s v1alpha1.SealingScope) bool { return s != scopes[0] }) {
+		c.Header(HeaderSealingScope, scopes[0].String())
+	}
+	c.Data(http.StatusOK, outputContentType, out)
+}
+
+// sealDocument validates and seals a single secret manifest. On error, the response status is returned.
+func (h *Handler) sealDocument(
+	c *gin.Context,
+	sealer seal.Sealer,
+	doc []byte,
+	outputFormat string,
+) ([]byte, v1alpha1.SealingScope, int, error) {
+	if err := validateBase64Data(doc); err != nil {
+		return nil, v1alpha1.DefaultScope, http.StatusUnprocessableEntity, err
+	}
+	secret, err := parseSecret(doc)
+	if err != nil {
+		return nil, v1alpha1.DefaultScope, http.StatusUnprocessableEntity, err
+	}
+	if err := validateSecret(secret); err != nil {
+		return nil, v1alpha1.DefaultScope, http.StatusUnprocessableEntity, err
+	}
+
+	scope, err := sealingScope(c, secret)
+	if err != nil {
+		return nil, scope, http.StatusUnprocessableEntity, err
+	}
+
+	if err := h.checkPolicy(c, policy.FromSecret(secret, scope)); err != nil {
+		return nil, scope, http.StatusForbidden, err
+	}
+
+	// the checked object is sealed, not the manifest that might be read differently by the sealer
+	manifest, err := encodeSecretManifest(secret)
+	if err != nil {
+		return nil, scope, http.StatusInternalServerError, err
+	}
+	ss, err := sealer.Seal(outputFormat, scope, bytes.NewReader(manifest))
+	if err == nil {
+		ss, err = filterSealedSecret(h.filter, ss, outputFormat)
+	}
+	if err != nil {
+		return nil, scope, http.StatusInternalServerError, err
+	}
+	return ss, scope, http.StatusOK, nil
+}
+
+// sealingScope evaluates the scope to seal the secret with.
+// A scope requested via query parameter or header takes precedence. If none or the default (strict) scope
+// is requested, the sealedsecrets.bitnami.com scope annotations of the input secret are honoured.
+func sealingScope(c *gin.Context, secret *corev1.Secret) (v1alpha1.SealingScope, error) {
+	requested := c.Query(queryScope)
+	if requested == "" {
+		requested = c.GetHeader(HeaderSealingScope)
+	}
+
+	scope := v1alpha1.DefaultScope
+	if err := scope.Set(requested); err != nil {
+		return scope, fmt.Errorf("invalid scope '%s': %w", requested, err)
+	}
+	if scope != v1alpha1.DefaultScope {
+		return scope, nil
+	}
+
+	return v1alpha1.SecretScope(secret), nil
 }
 
 // validateBase64Data parses the body as a raw map to get the original string
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/mock/gomock"
 
@@ -40,7 +41,7 @@ var _ = Describe("Handler ", func() {
 			c.Request.Header.Set("Content-Type", "application/json")
 			c.Request.Header.Set("Accept", "application/json")
 
-			sealer.EXPECT().Seal("json", gomock.Any()).Return([]byte(sealAsJSON), nil)
+			sealer.EXPECT().Seal("json", v1alpha1.StrictScope, gomock.Any()).Return([]byte(sealAsJSON), nil)
 
 			h.KubeSeal(c)
 
@@ -54,7 +55,7 @@ var _ = Describe("Handler ", func() {
 			c.Request.Header.Set("Content-Type", "application/yaml")
 			c.Request.Header.Set("Accept", "application/yaml")
 
-			sealer.EXPECT().Seal("yaml", gomock.Any()).Return([]byte(sealedAsYAML), nil)
+			sealer.EXPECT().Seal("yaml", v1alpha1.StrictScope, gomock.Any()).Return([]byte(sealedAsYAML), nil)
 
 			h.KubeSeal(c)
 
@@ -68,7 +69,7 @@ var _ = Describe("Handler ", func() {
 			c.Request.Header.Set("Content-Type", "application/yaml")
 			c.Request.Header.Set("Accept", "application/yaml")
 
-			sealer.EXPECT().Seal(gomock.Any(), gomock.Any()).Return(nil, errors.New("error sealing"))
+			sealer.EXPECT().Seal(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("error sealing"))
 
 			h.KubeSeal(c)
 
@@ -82,7 +83,7 @@ var _ = Describe("Handler ", func() {
 			c.Request.Header.Set("Content-Type", "application/json")
 			c.Request.Header.Set("Accept", "application/json")
 
-			sealer.EXPECT().Seal("json", gomock.Any()).Return([]byte(sealAsJSON), nil)
+			sealer.EXPECT().Seal("json", v1alpha1.StrictScope, gomock.Any()).Return([]byte(sealAsJSON), nil)
 
 			h.KubeSeal(c)
 
@@ -121,13 +122,68 @@ var _ = Describe("Handler ", func() {
 			c.Request.Header.Set("Content-Type", "application/json")
 			c.Request.Header.Set("Accept", "application/json")
 
-			sealer.EXPECT().Seal("json", gomock.Any()).Return([]byte(sealAsJSON), nil)
+			sealer.EXPECT().Seal("json", v1alpha1.StrictScope, gomock.Any()).Return([]byte(sealAsJSON), nil)
 
 			h.KubeSeal(c)
 
 			Ω(recorder.Code).Should(Equal(http.StatusOK))
 		})
 
+		It("should kubeseal with the scope given as query parameter", func() {
+			c.Request, _ = http.NewRequest(
+				http.MethodPost,
+				"/v1/kubeseal?scope=cluster-wide",
+				bytes.NewReader([]byte(stringDataAsYAML)),
+			)
+			c.Request.Header.Set("Content-Type", "application/yaml")
+			c.Request.Header.Set("Accept", "application/yaml")
+
+			sealer.EXPECT().Seal("yaml", v1alpha1.ClusterWideScope, gomock.Any()).Return([]byte(sealedAsYAML), nil)
+
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Header().Get(HeaderSealingScope)).Should(Equal("cluster-wide"))
+		})
+
+		It("should kubeseal with the scope given as header", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/v1/kubeseal", bytes.NewReader([]byte(stringDataAsYAML)))
+			c.Request.Header.Set("Content-Type", "application/yaml")
+			c.Request.Header.Set("Accept", "application/yaml")
+			c.Request.Header.Set(HeaderSealingScope, "namespace-wide")
+
+			sealer.EXPECT().Seal("yaml", v1alpha1.NamespaceWideScope, gomock.Any()).Return([]byte(sealedAsYAML), nil)
+
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Header().Get(HeaderSealingScope)).Should(Equal("namespace-wide"))
+		})
+
+		It("should honour the scope annotation of the secret", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/v1/kubeseal", bytes.NewReader([]byte(namespaceWideAsYAML)))
+			c.Request.Header.Set("Content-Type", "application/yaml")
+			c.Request.Header.Set("Accept", "application/yaml")
+
+			sealer.EXPECT().Seal("yaml", v1alpha1.NamespaceWideScope, gomock.Any()).Return([]byte(sealedAsYAML), nil)
+
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Header().Get(HeaderSealingScope)).Should(Equal("namespace-wide"))
+		})
+
+		It("should return 422 if the scope is invalid", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/v1/kubeseal?scope=foo", bytes.NewReader([]byte(stringDataAsJSON)))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request.Header.Set("Accept", "application/json")
+
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+			Ω(recorder.Body.String()).Should(ContainSubstring("invalid scope 'foo'"))
+		})
+
 		It("should return 422 if .data contains mixed valid and invalid base64", func() {
 			c.Request, _ = http.NewRequest(http.MethodPost, "/v1/kubeseal", bytes.NewReader([]byte(mixedBase64DataAsJSON)))
 			c.Request.Header.Set("Content-Type", "application/json")
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"log"
+	"maps"
+	"net/http"
+	"strings"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/bakito/sealed-secrets-web/pkg/audit"
+	"github.com/bakito/sealed-secrets-web/pkg/metrics"
+	"github.com/bakito/sealed-secrets-web/pkg/policy"
+)
+
+type merge struct {
+	SealedSecret string `json:"sealedSecret"`
+	Secret       string `json:"secret"`
+}
+
+// Merge adds or replaces the keys of a partial secret in an existing SealedSecret.
+func (h *Handler) Merge(c *gin.Context) {
+	outputContentType, outputFormat, done := NegotiateFormat(c)
+	if done {
+		return
+	}
+
+	defer h.observe(c, metrics.OperationMerge)
+	ev, logAudit := startAudit(h.auditor, h.cfg, c, audit.OperationMerge)
+	defer logAudit()
+	ev.Target = h.selectedTarget(c)
+
+	sealer, err := h.sealerFor(c)
+	if err != nil {
+		mergeError(c, outputContentType, http.StatusNotFound, err)
+		return
+	}
+
+	data := &merge{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		mergeError(c, outputContentType, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if err := validateBase64Data([]byte(data.Secret)); err != nil {
+		mergeError(c, outputContentType, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	sec, err := readSecret(scheme.Codecs.UniversalDecoder(), strings.NewReader(data.Secret))
+	if err != nil {
+		log.Printf("Error in %s: %s\n", Sanitize(c.FullPath()), Sanitize(err.Error()))
+		mergeError(c, outputContentType, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	sealedSecret, err := readSealedSecret(scheme.Codecs.UniversalDecoder(), strings.NewReader(data.SealedSecret))
+	if err != nil {
+		log.Printf("Error in %s: %s\n", Sanitize(c.FullPath()), Sanitize(err.Error()))
+		mergeError(c, outputContentType, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	auditSecret(ev, sec)
+	ev.Namespace = sealedSecret.Namespace
+	ev.Name = sealedSecret.Name
+	scope := sealedSecret.Scope()
+	ev.Scope = scope.String()
+
+	merged := mergedSecret(sealedSecret, sec)
+	if err := validateSecret(merged); err != nil {
+		mergeError(c, outputContentType, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err := h.checkPolicy(c, policy.FromSecret(merged, scope)); err != nil {
+		mergeError(c, outputContentType, http.StatusForbidden, err)
+		return
+	}
+
+	ss, err := sealer.Merge(outputFormat, sealedSecret, sec)
+	if err == nil {
+		ss, err = filterSealedSecret(h.filter, ss, outputFormat)
+	}
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		mergeError(c, outputContentType, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Data(http.StatusOK, outputContentType, ss)
+}
+
+// mergedSecret returns the secret the SealedSecret produces after the merge, to validate it and check the policy.
+// The values of the keys already sealed are unknown and left nil.
+func mergedSecret(sealedSecret *v1alpha1.SealedSecret, sec *corev1.Secret) *corev1.Secret {
+	merged := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   sealedSecret.Namespace,
+			Name:        sealedSecret.Name,
+			Labels:      sealedSecret.Spec.Template.Labels,
+			Annotations: sealedSecret.Spec.Template.Annotations,
+		},
+		Type:       sealedSecret.Spec.Template.Type,
+		Data:       make(map[string][]byte),
+		StringData: sec.StringData,
+	}
+	for k := range sealedSecret.Spec.EncryptedData {
+		merged.Data[k] = nil
+	}
+	for k, v := range sealedSecret.Spec.Template.Data {
+		merged.Data[k] = []byte(v)
+	}
+	maps.Copy(merged.Data, sec.Data)
+	return merged
+}
+
+func mergeError(c *gin.Context, outputContentType string, code int, err error) {
+	contextNegotiate(c, code, gin.Negotiate{
+		Offered: []string{outputContentType},
+		Data:    errorResponse(err),
+	})
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/bakito/sealed-secrets-web/pkg/mocks/seal"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Handler ", func() {
+	Context("Merge", func() {
+		var (
+			recorder *httptest.ResponseRecorder
+			c        *gin.Context
+			mock     *gomock.Controller
+			sealer   *seal.MockSealer
+			h        *Handler
+		)
+		BeforeEach(func() {
+			gin.SetMode(gin.ReleaseMode)
+			recorder = httptest.NewRecorder()
+			c, _ = gin.CreateTestContext(recorder)
+			mock = gomock.NewController(GinkgoT())
+			sealer = seal.NewMockSealer(mock)
+			h = &Handler{
+				sealer: sealer,
+			}
+		})
+
+		mergeRequest := func(sealedSecret, secret string) *http.Request {
+			body, err := json.Marshal(merge{SealedSecret: sealedSecret, Secret: secret})
+			Ω(err).ShouldNot(HaveOccurred())
+			req, _ := http.NewRequest(http.MethodPost, "/v1/kubeseal/merge", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/yaml")
+			return req
+		}
+
+		It("should merge the secret into the sealed secret", func() {
+			c.Request = mergeRequest(existingSealedSecretAsYAML, stringDataAsYAML)
+
+			sealer.EXPECT().Merge("yaml", gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ string, ss *v1alpha1.SealedSecret, sec *corev1.Secret) ([]byte, error) {
+					Ω(ss.Name).Should(Equal("mysecretname"))
+					Ω(ss.Spec.EncryptedData).Should(HaveKeyWithValue("password", "AgBpassword=="))
+					Ω(sec.StringData).Should(HaveKeyWithValue("username", "admin"))
+					return []byte(sealedAsYAML), nil
+				})
+
+			h.Merge(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Body.String()).Should(Equal(sealedAsYAML))
+			Ω(recorder.Header().Get("Content-Type")).Should(Equal("application/yaml"))
+		})
+
+		It("should return 422 if the body is not valid json", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/v1/kubeseal/merge", bytes.NewReader([]byte("foo")))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request.Header.Set("Accept", "application/json")
+
+			h.Merge(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+		})
+
+		It("should return 422 if the secret contains invalid base64", func() {
+			c.Request = mergeRequest(existingSealedSecretAsYAML, invalidBase64DataAsYAML)
+
+			h.Merge(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+			Ω(recorder.Body.String()).Should(ContainSubstring(errInvalidBase64))
+		})
+
+		It("should return 422 if the sealed secret can not be parsed", func() {
+			c.Request = mergeRequest(stringDataAsYAML, stringDataAsYAML)
+
+			h.Merge(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+		})
+
+		It("should return 422 if the merged secret is invalid", func() {
+			c.Request = mergeRequest(existingSealedSecretAsYAML, "apiVersion: v1\nkind: Secret\nstringData:\n  in valid: x\n")
+
+			h.Merge(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+			Ω(recorder.Body.String()).Should(ContainSubstring("stringData[in valid]"))
+		})
+
+		It("should not check the unknown values of the sealed keys", func() {
+			c.Request = mergeRequest(
+				strings.Replace(existingSealedSecretAsYAML, "      namespace: mysecretnamespace\n",
+					"      namespace: mysecretnamespace\n    type: kubernetes.io/dockerconfigjson\n", 1),
+				"apiVersion: v1\nkind: Secret\nstringData:\n  .dockerconfigjson: '{}'\n")
+			sealer.EXPECT().Merge("yaml", gomock.Any(), gomock.Any()).Return([]byte(sealedAsYAML), nil)
+
+			h.Merge(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+		})
+
+		It("should return an error if merge is not successful", func() {
+			c.Request = mergeRequest(existingSealedSecretAsYAML, stringDataAsYAML)
+
+			sealer.EXPECT().Merge(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("error merging"))
+
+			h.Merge(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusInternalServerError))
+			Ω(recorder.Body.String()).Should(Equal("error: error merging\n"))
+		})
+	})
+})
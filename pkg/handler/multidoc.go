@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bakito/sealed-secrets-web/pkg/audit"
+	"github.com/bakito/sealed-secrets-web/pkg/policy"
+	"github.com/bakito/sealed-secrets-web/pkg/seal"
+)
+
+const yamlSeparator = "---\n"
+
+// DocumentError is the error of a single document of a multi-document request.
+type DocumentError struct {
+	Index      int                `json:"index"`
+	Namespace  string             `json:"namespace,omitempty"`
+	Name       string             `json:"name,omitempty"`
+	Error      string             `json:"error"`
+	Fields     []FieldError       `json:"fields,omitempty"`
+	Violations []policy.Violation `json:"violations,omitempty"`
+}
+
+// manifestList is a v1 List of manifests.
+type manifestList struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Items      []json.RawMessage `json:"items"`
+}
+
+// splitDocuments splits the body into its yaml documents. The items of v1 Lists are returned as separate documents.
+// isList is true if the body is a single List.
+func splitDocuments(body []byte) (docs [][]byte, isList bool, err error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(body)))
+	var documents, lists int
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		// skip empty documents, e.g. a leading separator or only comments
+		var v any
+		if err := yaml.Unmarshal(doc, &v); err == nil && v == nil {
+			continue
+		}
+		documents++
+
+		// an unparsable document is kept, to be reported with its index when sealing
+		var list manifestList
+		if err := yaml.Unmarshal(doc, &list); err == nil && list.APIVersion == "v1" && list.Kind == "List" {
+			lists++
+			docs = append(docs, toBytes(list.Items)...)
+			continue
+		}
+		docs = append(docs, bytes.TrimPrefix(doc, []byte(yamlSeparator)))
+	}
+	return docs, documents == 1 && lists == 1, nil
+}
+
+func toBytes(items []json.RawMessage) [][]byte {
+	docs := make([][]byte, len(items))
+	for i, item := range items {
+		docs[i] = item
+	}
+	return docs
+}
+
+// sealDocuments seals each document and returns the sealed secrets in the same order.
+// All documents are sealed, the errors are reported per document. The returned function logs the audit events
+// of the documents and must be called after the response is written: a failed document is recorded with its own
+// status, the others with the status of the response, as they are not returned if the request fails.
+func (h *Handler) sealDocuments(
+	c *gin.Context,
+	sealer seal.Sealer,
+	docs [][]byte,
+	outputFormat string,
+) (sealed [][]byte, scopes []v1alpha1.SealingScope, status int, docErrs []DocumentError, logAudit func()) {
+	status = http.StatusOK
+	var logs []func()
+	logAudit = func() {
+		for _, l := range logs {
+			l()
+		}
+	}
+
+	for i, doc := range docs {
+		ev, logDocument := startAudit(h.auditor, h.cfg, c, audit.OperationSeal)
+		logs = append(logs, logDocument)
+		ev.Target = h.selectedTarget(c)
+		auditSecretManifest(ev, doc)
+
+		ss, scope, code, err := h.sealDocument(c, sealer, doc, outputFormat)
+		ev.Scope = scope.String()
+		if err != nil {
+			ev.Status = code
+			docErr := DocumentError{Index: i, Error: err.Error()}
+			var invalid *SecretInvalidError
+			if errors.As(err, &invalid) {
+				docErr.Fields = invalid.Fields
+			}
+			var denied *policy.DeniedError
+			if errors.As(err, &denied) {
+				docErr.Violations = denied.Violations
+			}
+			var meta struct {
+				Metadata struct {
+					Namespace string `json:"namespace"`
+					Name      string `json:"name"`
+				} `json:"metadata"`
+			}
+			if yaml.Unmarshal(doc, &meta) == nil {
+				docErr.Namespace = meta.Metadata.Namespace
+				docErr.Name = meta.Metadata.Name
+			}
+			docErrs = append(docErrs, docErr)
+			status = max(status, code)
+			continue
+		}
+		sealed = append(sealed, ss)
+		scopes = append(scopes, scope)
+	}
+	return sealed, scopes, status, docErrs, logAudit
+}
+
+// joinSealed combines the sealed secrets into a multi-document yaml stream, or into a v1 List
+// if the input was a List or the output is json.
+func joinSealed(sealed [][]byte, asList bool, outputFormat string) ([]byte, error) {
+	if !asList && outputFormat == "yaml" {
+		var buf bytes.Buffer
+		for i, ss := range sealed {
+			if i > 0 {
+				buf.WriteString(yamlSeparator)
+			}
+			buf.Write(ss)
+			if !bytes.HasSuffix(ss, []byte("\n")) {
+				buf.WriteString("\n")
+			}
+		}
+		return buf.Bytes(), nil
+	}
+
+	list := manifestList{APIVersion: "v1", Kind: "List", Items: []json.RawMessage{}}
+	for _, ss := range sealed {
+		item, err := yaml.YAMLToJSON(ss)
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, item)
+	}
+	if outputFormat == "yaml" {
+		return yaml.Marshal(list)
+	}
+	return json.MarshalIndent(list, "", "  ")
+}
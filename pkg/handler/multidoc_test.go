@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bakito/sealed-secrets-web/pkg/mocks/seal"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	secretA = `apiVersion: v1
+kind: Secret
+metadata:
+  name: a
+  namespace: ns
+stringData:
+  username: admin
+`
+	secretB = `apiVersion: v1
+kind: Secret
+metadata:
+  name: b
+  namespace: ns
+  annotations:
+    sealedsecrets.bitnami.com/namespace-wide: "true"
+stringData:
+  password: admin
+`
+	secretList = `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "a", "namespace": "ns"}, "stringData": {"username": "admin"}},
+    {"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "b", "namespace": "ns"}, "stringData": {"password": "admin"}}
+  ]
+}`
+)
+
+var _ = Describe("Multi document", func() {
+	Context("splitDocuments", func() {
+		It("should split the yaml stream and skip empty documents", func() {
+			docs, isList, err := splitDocuments([]byte("---\n" + secretA + "---\n# only a comment\n---\n" + secretB))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(isList).Should(BeFalse())
+			Ω(docs).Should(HaveLen(2))
+			Ω(string(docs[0])).Should(Equal(secretA))
+			Ω(string(docs[1])).Should(Equal(secretB))
+		})
+
+		It("should split the items of a list", func() {
+			docs, isList, err := splitDocuments([]byte(secretList))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(isList).Should(BeTrue())
+			Ω(docs).Should(HaveLen(2))
+			Ω(string(docs[1])).Should(ContainSubstring(`"name":"b"`))
+		})
+
+		It("should return a single document", func() {
+			docs, isList, err := splitDocuments([]byte(secretA))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(isList).Should(BeFalse())
+			Ω(docs).Should(HaveLen(1))
+		})
+	})
+
+	Context("KubeSeal", func() {
+		var (
+			recorder *httptest.ResponseRecorder
+			c        *gin.Context
+			sealer   *seal.MockSealer
+			h        *Handler
+		)
+		BeforeEach(func() {
+			gin.SetMode(gin.ReleaseMode)
+			recorder = httptest.NewRecorder()
+			c, _ = gin.CreateTestContext(recorder)
+			sealer = seal.NewMockSealer(gomock.NewController(GinkgoT()))
+			h = &Handler{sealer: sealer}
+		})
+
+		// sealByName returns a fake sealed secret with the name of the secret
+		sealByName := func(_ string, _ v1alpha1.SealingScope, r io.Reader) ([]byte, error) {
+			data, err := io.ReadAll(r)
+			Ω(err).ShouldNot(HaveOccurred())
+			var sec struct {
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+			}
+			Ω(yaml.Unmarshal(data, &sec)).Should(Succeed())
+			return []byte("apiVersion: bitnami.com/v1alpha1\nkind: SealedSecret\nmetadata:\n  name: " +
+				sec.Metadata.Name + "\n"), nil
+		}
+
+		It("should seal a yaml stream in the same order", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/kubeseal", bytes.NewReader([]byte(secretA+"---\n"+secretB)))
+			c.Request.Header.Set("Accept", "application/yaml")
+			sealer.EXPECT().Seal("yaml", v1alpha1.StrictScope, gomock.Any()).DoAndReturn(sealByName)
+			sealer.EXPECT().Seal("yaml", v1alpha1.NamespaceWideScope, gomock.Any()).DoAndReturn(sealByName)
+
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Body.String()).Should(Equal(`apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: a
+---
+apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: b
+`))
+			Ω(recorder.Header().Get(HeaderSealingScope)).Should(BeEmpty())
+		})
+
+		It("should seal a list into a list", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/kubeseal", bytes.NewReader([]byte(secretList)))
+			c.Request.Header.Set("Accept", "application/json")
+			sealer.EXPECT().Seal("json", v1alpha1.StrictScope, gomock.Any()).DoAndReturn(sealByName).Times(2)
+
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Body.String()).Should(Equal(`{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {
+      "apiVersion": "bitnami.com/v1alpha1",
+      "kind": "SealedSecret",
+      "metadata": {
+        "name": "a"
+      }
+    },
+    {
+      "apiVersion": "bitnami.com/v1alpha1",
+      "kind": "SealedSecret",
+      "metadata": {
+        "name": "b"
+      }
+    }
+  ]
+}`))
+			Ω(recorder.Header().Get(HeaderSealingScope)).Should(Equal("strict"))
+		})
+
+		It("should report the errors per document", func() {
+			invalid := "apiVersion: v1\nkind: Secret\nmetadata:\n  name: c\n  namespace: ns\ndata:\n  password: '%%%'\n"
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/kubeseal",
+				bytes.NewReader([]byte(secretA+"---\n"+invalid+"---\n"+secretB)))
+			c.Request.Header.Set("Accept", "application/json")
+			sealer.EXPECT().Seal("json", v1alpha1.StrictScope, gomock.Any()).DoAndReturn(sealByName)
+			sealer.EXPECT().Seal("json", v1alpha1.NamespaceWideScope, gomock.Any()).Return(nil, errors.New("error sealing"))
+
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusInternalServerError))
+			Ω(recorder.Body.String()).Should(MatchJSON(`{
+  "error": "2 of 3 documents could not be sealed",
+  "documents": [
+    {"index": 1, "namespace": "ns", "name": "c", "error": "` + errInvalidBase64 + `"},
+    {"index": 2, "namespace": "ns", "name": "b", "error": "error sealing"}
+  ]
+}`))
+		})
+	})
+})
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"mime"
+	"slices"
+	"strings"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	queryOutput   = "output"
+	queryHelmPath = "helmPath"
+
+	// OutputManifest is the full SealedSecret manifest.
+	OutputManifest = "manifest"
+	// OutputEncryptedData is the bare encryptedData map.
+	OutputEncryptedData = "encrypted-data"
+	// OutputHelmValues is the encryptedData map nested under the helm key path.
+	OutputHelmValues = "helm-values"
+	// OutputStrategicMergePatch is a kustomize strategic merge patch of the SealedSecret.
+	OutputStrategicMergePatch = "strategic-merge-patch"
+	// OutputJSONPatch is a kustomize JSON6902 patch adding the encrypted values.
+	OutputJSONPatch = "json-patch"
+
+	defaultHelmPath = "encryptedData"
+)
+
+var outputModes = []string{
+	OutputManifest,
+	OutputEncryptedData,
+	OutputHelmValues,
+	OutputStrategicMergePatch,
+	OutputJSONPatch,
+}
+
+// outputMode returns the requested output mode, from the output query parameter or the profile parameter of the
+// accepted media type, e.g. 'application/yaml; profile=helm-values'. The helm key path is a dot separated list of keys.
+func outputMode(c *gin.Context, contentType string) (string, error) {
+	mode := c.Query(queryOutput)
+	if mode == "" {
+		for accept := range strings.SplitSeq(c.GetHeader("Accept"), ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+			if err == nil && mediaType == contentType && params["profile"] != "" {
+				mode = params["profile"]
+				break
+			}
+		}
+	}
+	if mode == "" {
+		return OutputManifest, nil
+	}
+	if !slices.Contains(outputModes, mode) {
+		return "", fmt.Errorf("invalid output '%s', must be one of %s", mode, strings.Join(outputModes, ", "))
+	}
+	if path := c.DefaultQuery(queryHelmPath, defaultHelmPath); mode == OutputHelmValues &&
+		slices.Contains(strings.Split(path, "."), "") {
+		return "", fmt.Errorf("invalid helm path '%s'", path)
+	}
+	return mode, nil
+}
+
+// jsonPatchOperation is an operation of a JSON6902 patch.
+type jsonPatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value string `json:"value"`
+}
+
+// renderOutput renders the sealed secret manifest in the output mode.
+func renderOutput(c *gin.Context, mode string, sealed []byte, outputFormat string) ([]byte, error) {
+	if mode == OutputManifest {
+		return sealed, nil
+	}
+
+	ss := &v1alpha1.SealedSecret{}
+	if err := yaml.Unmarshal(sealed, ss); err != nil {
+		return nil, err
+	}
+	encryptedData := map[string]string(ss.Spec.EncryptedData)
+	if encryptedData == nil {
+		encryptedData = map[string]string{}
+	}
+
+	var out any
+	switch mode {
+	case OutputEncryptedData:
+		out = encryptedData
+	case OutputHelmValues:
+		out = any(encryptedData)
+		for _, key := range slices.Backward(strings.Split(c.DefaultQuery(queryHelmPath, defaultHelmPath), ".")) {
+			out = map[string]any{key: out}
+		}
+	case OutputStrategicMergePatch:
+		metadata := map[string]any{"name": ss.Name}
+		if ss.Namespace != "" {
+			metadata["namespace"] = ss.Namespace
+		}
+		if len(ss.Annotations) > 0 {
+			metadata["annotations"] = ss.Annotations
+		}
+		out = map[string]any{
+			"apiVersion": ss.APIVersion,
+			"kind":       ss.Kind,
+			"metadata":   metadata,
+			"spec":       map[string]any{"encryptedData": encryptedData},
+		}
+	case OutputJSONPatch:
+		ops := []jsonPatchOperation{}
+		for _, key := range slices.Sorted(maps.Keys(encryptedData)) {
+			ops = append(ops, jsonPatchOperation{
+				Op:    "add",
+				Path:  "/spec/encryptedData/" + escapeJSONPointer(key),
+				Value: encryptedData[key],
+			})
+		}
+		out = ops
+	}
+
+	if outputFormat == "yaml" {
+		return yaml.Marshal(out)
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// escapeJSONPointer escapes a reference token of a JSON pointer (RFC 6901).
+func escapeJSONPointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
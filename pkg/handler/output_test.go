@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"github.com/bakito/sealed-secrets-web/pkg/mocks/seal"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Output", func() {
+	const sealedManifest = `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  annotations:
+    sealedsecrets.bitnami.com/namespace-wide: "true"
+  name: mysecret
+  namespace: mynamespace
+spec:
+  encryptedData:
+    a/b: AgBy
+    password: AgBx
+  template:
+    metadata:
+      name: mysecret
+      namespace: mynamespace
+`
+	var (
+		recorder *httptest.ResponseRecorder
+		c        *gin.Context
+		sealer   *seal.MockSealer
+		h        *Handler
+	)
+	BeforeEach(func() {
+		gin.SetMode(gin.ReleaseMode)
+		recorder = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(recorder)
+		sealer = seal.NewMockSealer(gomock.NewController(GinkgoT()))
+		h = &Handler{sealer: sealer}
+	})
+
+	kubeSeal := func(url, accept string) {
+		c.Request, _ = http.NewRequest(http.MethodPost, url, bytes.NewReader([]byte(stringDataAsYAML)))
+		c.Request.Header.Set("Content-Type", "application/yaml")
+		c.Request.Header.Set("Accept", accept)
+		h.KubeSeal(c)
+	}
+
+	It("should render the manifest by default", func() {
+		sealer.EXPECT().Seal("yaml", v1alpha1.StrictScope, gomock.Any()).Return([]byte(sealedManifest), nil)
+		kubeSeal("/v1/kubeseal", "application/yaml")
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(Equal(sealedManifest))
+	})
+
+	It("should render the encrypted data selected by query parameter", func() {
+		sealer.EXPECT().Seal("yaml", v1alpha1.StrictScope, gomock.Any()).Return([]byte(sealedManifest), nil)
+		kubeSeal("/v1/kubeseal?output=encrypted-data", "application/yaml")
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(Equal("a/b: AgBy\npassword: AgBx\n"))
+		Ω(recorder.Header().Get("Content-Type")).Should(Equal("application/yaml"))
+	})
+
+	It("should render the encrypted data selected by accept profile as json", func() {
+		sealer.EXPECT().Seal("json", v1alpha1.StrictScope, gomock.Any()).Return([]byte(sealedManifest), nil)
+		kubeSeal("/v1/kubeseal", "application/json; profile=encrypted-data")
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(Equal("{\n  \"a/b\": \"AgBy\",\n  \"password\": \"AgBx\"\n}"))
+		Ω(recorder.Header().Get("Content-Type")).Should(Equal("application/json"))
+	})
+
+	It("should render the helm values under the key path", func() {
+		sealer.EXPECT().Seal("yaml", v1alpha1.StrictScope, gomock.Any()).Return([]byte(sealedManifest), nil)
+		kubeSeal("/v1/kubeseal?helmPath=secrets.db.encryptedData", "application/yaml; profile=helm-values")
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(Equal(`secrets:
+  db:
+    encryptedData:
+      a/b: AgBy
+      password: AgBx
+`))
+	})
+
+	It("should render a strategic merge patch", func() {
+		sealer.EXPECT().Seal("yaml", v1alpha1.StrictScope, gomock.Any()).Return([]byte(sealedManifest), nil)
+		kubeSeal("/v1/kubeseal?output=strategic-merge-patch", "application/yaml")
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(Equal(`apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  annotations:
+    sealedsecrets.bitnami.com/namespace-wide: "true"
+  name: mysecret
+  namespace: mynamespace
+spec:
+  encryptedData:
+    a/b: AgBy
+    password: AgBx
+`))
+	})
+
+	It("should render a json patch", func() {
+		sealer.EXPECT().Seal("yaml", v1alpha1.StrictScope, gomock.Any()).Return([]byte(sealedManifest), nil)
+		kubeSeal("/v1/kubeseal?output=json-patch", "application/yaml")
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(Equal(`- op: add
+  path: /spec/encryptedData/a~1b
+  value: AgBy
+- op: add
+  path: /spec/encryptedData/password
+  value: AgBx
+`))
+	})
+
+	It("should reject an unknown output", func() {
+		kubeSeal("/v1/kubeseal?output=foo", "application/json")
+
+		Ω(recorder.Code).Should(Equal(http.StatusBadRequest))
+		Ω(recorder.Body.String()).Should(Equal(`{"error":"invalid output 'foo', must be one of manifest, ` +
+			`encrypted-data, helm-values, strategic-merge-patch, json-patch"}`))
+	})
+
+	It("should reject an invalid helm path", func() {
+		kubeSeal("/v1/kubeseal?output=helm-values&helmPath=a..b", "application/json")
+
+		Ω(recorder.Code).Should(Equal(http.StatusBadRequest))
+		Ω(recorder.Body.String()).Should(Equal(`{"error":"invalid helm path 'a..b'"}`))
+	})
+
+	It("should reject multiple documents for other outputs than the manifest", func() {
+		c.Request, _ = http.NewRequest(http.MethodPost, "/v1/kubeseal?output=encrypted-data",
+			bytes.NewReader([]byte(stringDataAsYAML+"---\n"+stringDataAsYAML)))
+		c.Request.Header.Set("Content-Type", "application/yaml")
+		c.Request.Header.Set("Accept", "application/json")
+		h.KubeSeal(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusBadRequest))
+		Ω(recorder.Body.String()).Should(Equal(`{"error":"output 'encrypted-data' supports a single secret only"}`))
+	})
+})
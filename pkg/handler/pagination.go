@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	queryLimit         = "limit"
+	queryContinue      = "continue"
+	queryLabelSelector = "labelSelector"
+	querySearch        = "q"
+	querySynced        = "synced"
+	queryDrift         = "drift"
+)
+
+// SecretList is a page of secrets.
+type SecretList struct {
+	Secrets  []Secret `json:"secrets"`
+	Total    int      `json:"total"`              // number of secrets matching the filters
+	Continue string   `json:"continue,omitempty"` // token to request the next page
+}
+
+// listQuery holds the pagination and filter parameters of the secret list.
+type listQuery struct {
+	limit    int
+	after    *Secret
+	selector labels.Selector
+	search   string
+	synced   *bool
+	drift    bool
+}
+
+// parseListQuery reads the limit, continue, labelSelector, q, synced and drift query parameters.
+func parseListQuery(c *gin.Context) (*listQuery, error) {
+	q := &listQuery{
+		selector: labels.Everything(),
+		search:   strings.ToLower(strings.TrimSpace(c.Query(querySearch))),
+	}
+
+	if v := c.Query(queryLimit); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid limit '%s'", v)
+		}
+		q.limit = limit
+	}
+
+	if v := c.Query(queryContinue); v != "" {
+		after, err := decodeContinue(v)
+		if err != nil {
+			return nil, err
+		}
+		q.after = after
+	}
+
+	if v := c.Query(queryLabelSelector); v != "" {
+		selector, err := labels.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector: %w", err)
+		}
+		q.selector = selector
+	}
+
+	if v := c.Query(querySynced); v != "" {
+		synced, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid synced '%s'", v)
+		}
+		q.synced = &synced
+	}
+
+	if v := c.Query(queryDrift); v != "" {
+		drift, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid drift '%s'", v)
+		}
+		q.drift = drift
+	}
+	return q, nil
+}
+
+// matches checks the secret against the search text and the synced state.
+func (q *listQuery) matches(s Secret) bool {
+	if q.search != "" &&
+		!strings.Contains(strings.ToLower(s.Namespace), q.search) &&
+		!strings.Contains(strings.ToLower(s.Name), q.search) {
+		return false
+	}
+	if q.synced != nil && (s.Synced == nil || *s.Synced != *q.synced) {
+		return false
+	}
+	return true
+}
+
+// page filters the sorted secrets and returns the page after the continue token.
+func (q *listQuery) page(secrets []Secret) *SecretList {
+	list := &SecretList{Secrets: []Secret{}}
+	for _, s := range secrets {
+		if !q.matches(s) {
+			continue
+		}
+		list.Total++
+		if q.after != nil && compareSecrets(s, *q.after) <= 0 {
+			continue
+		}
+		if q.limit > 0 && len(list.Secrets) == q.limit {
+			list.Continue = encodeContinue(list.Secrets[len(list.Secrets)-1])
+			continue
+		}
+		list.Secrets = append(list.Secrets, s)
+	}
+	return list
+}
+
+// compareSecrets orders the secrets by namespace and name.
+func compareSecrets(a, b Secret) int {
+	if cmp := strings.Compare(a.Namespace, b.Namespace); cmp != 0 {
+		return cmp
+	}
+	return strings.Compare(a.Name, b.Name)
+}
+
+// encodeContinue creates the continue token pointing after the given secret.
+func encodeContinue(s Secret) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s.Namespace + "/" + s.Name))
+}
+
+func decodeContinue(token string) (*Secret, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid continue token")
+	}
+	ns, name, ok := strings.Cut(string(b), "/")
+	if !ok {
+		return nil, errors.New("invalid continue token")
+	}
+	return &Secret{Namespace: ns, Name: name}, nil
+}
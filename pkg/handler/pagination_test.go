@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pagination", func() {
+	var secrets []Secret
+
+	query := func(url string) (*listQuery, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest(http.MethodGet, url, http.NoBody)
+		return parseListQuery(c)
+	}
+
+	BeforeEach(func() {
+		secrets = []Secret{
+			{Namespace: "app", Name: "db", Synced: new(true)},
+			{Namespace: "app", Name: "web", Synced: new(false)},
+			{Namespace: "infra", Name: "backup"},
+			{Namespace: "infra", Name: "dns", Synced: new(true)},
+		}
+	})
+
+	It("should return all secrets without parameters", func() {
+		q, err := query("/api/secrets")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(q.selector.Empty()).Should(BeTrue())
+
+		list := q.page(secrets)
+		Ω(list.Secrets).Should(Equal(secrets))
+		Ω(list.Total).Should(Equal(4))
+		Ω(list.Continue).Should(BeEmpty())
+	})
+
+	It("should page through the secrets", func() {
+		q, err := query("/api/secrets?limit=3")
+		Ω(err).ShouldNot(HaveOccurred())
+		list := q.page(secrets)
+		Ω(list.Secrets).Should(Equal(secrets[:3]))
+		Ω(list.Total).Should(Equal(4))
+		Ω(list.Continue).ShouldNot(BeEmpty())
+
+		q, err = query("/api/secrets?limit=3&continue=" + list.Continue)
+		Ω(err).ShouldNot(HaveOccurred())
+		list = q.page(secrets)
+		Ω(list.Secrets).Should(Equal(secrets[3:]))
+		Ω(list.Total).Should(Equal(4))
+		Ω(list.Continue).Should(BeEmpty())
+	})
+
+	It("should filter by namespace or name", func() {
+		q, err := query("/api/secrets?q=INFRA")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(q.page(secrets).Secrets).Should(Equal(secrets[2:]))
+
+		q, err = query("/api/secrets?q=d")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(q.page(secrets).Total).Should(Equal(2))
+	})
+
+	DescribeTable("should filter by synced state",
+		func(value string, expected ...string) {
+			q, err := query("/api/secrets?synced=" + value)
+			Ω(err).ShouldNot(HaveOccurred())
+			var names []string
+			for _, s := range q.page(secrets).Secrets {
+				names = append(names, s.Name)
+			}
+			Ω(names).Should(Equal(expected))
+		},
+		Entry("synced", "true", "db", "dns"),
+		Entry("not synced", "false", "web"),
+	)
+
+	It("should parse the label selector", func() {
+		q, err := query("/api/secrets?labelSelector=team%3Da")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(q.selector.String()).Should(Equal("team=a"))
+	})
+
+	DescribeTable("should reject invalid parameters",
+		func(url string, msg string) {
+			_, err := query(url)
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring(msg))
+		},
+		Entry("limit", "/api/secrets?limit=-1", "invalid limit"),
+		Entry("continue", "/api/secrets?continue=%21", "invalid continue token"),
+		Entry("label selector", "/api/secrets?labelSelector=a%3D%3D%3Db", "invalid label selector"),
+		Entry("synced", "/api/secrets?synced=maybe", "invalid synced"),
+		Entry("drift", "/api/secrets?drift=maybe", "invalid drift"),
+	)
+})
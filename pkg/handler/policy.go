@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/bakito/sealed-secrets-web/pkg/policy"
+)
+
+// HeaderWarning reports the violated warn rules of the policy, like the warnings of the api server.
+const HeaderWarning = "Warning"
+
+// EnablePolicy evaluates the policy before sealing.
+func (h *Handler) EnablePolicy(p *policy.Policy) {
+	h.policy = p
+}
+
+// CheckPolicy evaluates the policy for the secret without sealing it.
+func (h *Handler) CheckPolicy(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		log.Printf("Error reading body in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	secret, err := parseSecret(body)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	scope, err := sealingScope(c, secret)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.policy.Evaluate(policy.FromSecret(secret, scope)))
+}
+
+// checkPolicy evaluates the policy for the input and reports the warnings. A DeniedError is returned if the
+// input is denied.
+func (h *Handler) checkPolicy(c *gin.Context, in policy.Input) error {
+	res := h.policy.Evaluate(in)
+	for _, w := range res.Warnings() {
+		c.Writer.Header().Add(HeaderWarning, fmt.Sprintf("299 - %q", w.String()))
+	}
+	return res.Err()
+}
+
+// parseSecret decodes the secret manifest with the codec of the sealer, so the policy and the validation
+// see the same object as the sealer.
+func parseSecret(doc []byte) (*corev1.Secret, error) {
+	obj, err := runtime.Decode(scheme.Codecs.UniversalDecoder(corev1.SchemeGroupVersion), doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret: %w", err)
+	}
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		return nil, fmt.Errorf("invalid secret: unexpected kind '%s'", obj.GetObjectKind().GroupVersionKind().Kind)
+	}
+	return secret, nil
+}
+
+// encodeSecretManifest encodes the secret as JSON, to seal exactly the decoded object.
+func encodeSecretManifest(secret *corev1.Secret) ([]byte, error) {
+	return runtime.Encode(scheme.Codecs.LegacyCodec(corev1.SchemeGroupVersion), secret)
+}
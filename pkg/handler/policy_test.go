@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/bakito/sealed-secrets-web/pkg/mocks/seal"
+	"github.com/bakito/sealed-secrets-web/pkg/policy"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Handler ", func() {
+	Context("Policy", func() {
+		const teamSecret = `apiVersion: v1
+kind: Secret
+metadata:
+  name: mysecret
+  namespace: prod-a
+  labels:
+    team: a
+stringData:
+  password: value
+`
+		var (
+			recorder *httptest.ResponseRecorder
+			c        *gin.Context
+			sealer   *seal.MockSealer
+			h        *Handler
+		)
+		BeforeEach(func() {
+			gin.SetMode(gin.ReleaseMode)
+			recorder = httptest.NewRecorder()
+			c, _ = gin.CreateTestContext(recorder)
+			sealer = seal.NewMockSealer(gomock.NewController(GinkgoT()))
+			p, err := policy.Parse([]byte(`
+rules:
+  - name: team-label
+    requiredLabels: [team]
+  - name: strict-in-prod
+    match:
+      namespaces: ["prod-*"]
+    allowedScopes: [strict]
+  - name: no-passwords
+    action: warn
+    forbiddenKeys: [password]
+`))
+			Ω(err).ShouldNot(HaveOccurred())
+			h = &Handler{sealer: sealer}
+			h.EnablePolicy(p)
+		})
+
+		It("should seal with the warnings of the policy", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/kubeseal", strings.NewReader(teamSecret))
+			c.Request.Header.Set("Accept", "application/yaml")
+			sealer.EXPECT().Seal("yaml", v1alpha1.StrictScope, gomock.Any()).Return([]byte(sealedAsYAML), nil)
+
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Header().Values(HeaderWarning)).Should(Equal([]string{
+				`299 - "no-passwords: key 'password' is not allowed"`,
+			}))
+		})
+
+		It("should not seal a denied secret", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/kubeseal?scope=cluster-wide",
+				strings.NewReader(strings.Replace(teamSecret, "team: a", "app: a", 1)))
+			c.Request.Header.Set("Accept", "application/json")
+
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusForbidden))
+			Ω(recorder.Body.String()).Should(ContainSubstring(
+				`"violations":[{"rule":"team-label","action":"deny","message":"label 'team' is required"},` +
+					`{"rule":"strict-in-prod","action":"deny","message":"scope 'cluster-wide' is not allowed, allowed are: strict"}]`,
+			))
+		})
+
+		It("should check the object that is sealed", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/kubeseal", strings.NewReader(`{
+  "apiVersion": "v1",
+  "kind": "Secret",
+  "metadata": {"name": "mysecret", "namespace": "prod-a"},
+  "Metadata": {"labels": {"team": "a"}},
+  "stringData": {"token": "value"}
+}`))
+			c.Request.Header.Set("Accept", "application/json")
+
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusForbidden))
+			Ω(recorder.Body.String()).Should(ContainSubstring(`"message":"label 'team' is required"`))
+		})
+
+		It("should not seal an input that can not be checked", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/kubeseal",
+				strings.NewReader("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n"))
+			c.Request.Header.Set("Accept", "application/json")
+
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+		})
+
+		It("should not seal a denied raw value", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/raw", strings.NewReader(
+				`{"name": "mysecret", "namespace": "prod-a", "value": "v", "scope": "namespace-wide"}`))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			h.Raw(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusForbidden))
+			Ω(recorder.Body.String()).Should(ContainSubstring(`"rule":"strict-in-prod"`))
+		})
+
+		It("should not seal a raw value with an invalid scope", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/raw", strings.NewReader(
+				`{"name": "mysecret", "namespace": "prod-a", "value": "v", "scope": "everywhere"}`))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			h.Raw(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+			Ω(recorder.Body.String()).Should(ContainSubstring(`invalid scope 'everywhere'`))
+		})
+
+		It("should check the policy without sealing", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/policy/check?scope=namespace-wide",
+				strings.NewReader(teamSecret))
+
+			h.CheckPolicy(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Body.String()).Should(Equal(`{"allowed":false,"violations":[` +
+				`{"rule":"strict-in-prod","action":"deny","message":"scope 'namespace-wide' is not allowed, allowed are: strict"},` +
+				`{"rule":"no-passwords","action":"warn","message":"key 'password' is not allowed"}]}`))
+		})
+
+		It("should not check an invalid input", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/policy/check",
+				strings.NewReader("apiVersion: v1\nkind: ConfigMap\n"))
+
+			h.CheckPolicy(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+			Ω(recorder.Body.String()).Should(Equal(`{"error":"invalid secret: unexpected kind 'ConfigMap'"}`))
+		})
+
+		Context("Merge", func() {
+			const prodSealedSecret = `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: mysecret
+  namespace: prod-a
+spec:
+  encryptedData:
+    token: AgBtoken==
+  template:
+    metadata:
+      name: mysecret
+      namespace: prod-a
+      labels:
+        team: a
+      annotations:
+        sealedsecrets.bitnami.com/cluster-wide: "true"
+`
+			mergeRequest := func(sealedSecret string) *http.Request {
+				body, err := json.Marshal(merge{
+					SealedSecret: sealedSecret,
+					Secret:       "apiVersion: v1\nkind: Secret\nstringData:\n  password: value\n",
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+				req, _ := http.NewRequest(http.MethodPost, "/api/kubeseal/merge", bytes.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Accept", "application/json")
+				return req
+			}
+
+			It("should not merge into a denied sealed secret", func() {
+				c.Request = mergeRequest(prodSealedSecret)
+
+				h.Merge(c)
+
+				Ω(recorder.Code).Should(Equal(http.StatusForbidden))
+				Ω(recorder.Body.String()).Should(ContainSubstring(
+					`"violations":[{"rule":"strict-in-prod","action":"deny",` +
+						`"message":"scope 'cluster-wide' is not allowed, allowed are: strict"}]`,
+				))
+			})
+
+			It("should merge with the warnings of the policy on the merged keys", func() {
+				c.Request = mergeRequest(strings.Replace(prodSealedSecret,
+					"      annotations:\n        sealedsecrets.bitnami.com/cluster-wide: \"true\"\n", "", 1))
+				sealer.EXPECT().Merge("json", gomock.Any(), gomock.Any()).Return([]byte(sealedAsYAML), nil)
+
+				h.Merge(c)
+
+				Ω(recorder.Code).Should(Equal(http.StatusOK))
+				Ω(recorder.Header().Values(HeaderWarning)).Should(Equal([]string{
+					`299 - "no-passwords: key 'password' is not allowed"`,
+				}))
+			})
+		})
+	})
+})
+
+func mustParseSecret(doc string) *corev1.Secret {
+	secret, err := parseSecret([]byte(doc))
+	Ω(err).ShouldNot(HaveOccurred())
+	return secret
+}
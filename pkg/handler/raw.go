@@ -1,11 +1,16 @@
 package handler
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
 	"github.com/gin-gonic/gin"
 
+	"github.com/bakito/sealed-secrets-web/pkg/audit"
+	"github.com/bakito/sealed-secrets-web/pkg/metrics"
+	"github.com/bakito/sealed-secrets-web/pkg/policy"
 	"github.com/bakito/sealed-secrets-web/pkg/seal"
 )
 
@@ -14,13 +19,43 @@ type secret struct {
 }
 
 func (h *Handler) Raw(c *gin.Context) {
+	defer h.observe(c, metrics.OperationRaw)
+	ev, logAudit := startAudit(h.auditor, h.cfg, c, audit.OperationSealRaw)
+	defer logAudit()
+	ev.Target = h.selectedTarget(c)
+
+	sealer, err := h.sealerFor(c)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
 	data := &seal.Raw{}
 	if err := c.ShouldBindJSON(&data); err != nil {
 		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 		return
 	}
-	r, err := h.sealer.Raw(*data)
+	ev.Namespace = data.Namespace
+	ev.Name = data.Name
+	ev.Scope = data.Scope
+
+	scope := v1alpha1.DefaultScope
+	if err := scope.Set(data.Scope); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("invalid scope '%s': %v", data.Scope, err)})
+		return
+	}
+	if err := h.checkPolicy(c, policy.Input{
+		Namespace: data.Namespace,
+		Name:      data.Name,
+		Scope:     scope,
+		Raw:       true,
+	}); err != nil {
+		c.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	r, err := sealer.Raw(*data)
 	if err != nil {
 		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
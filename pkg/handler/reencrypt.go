@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/bakito/sealed-secrets-web/pkg/audit"
+	"github.com/bakito/sealed-secrets-web/pkg/metrics"
+	"github.com/bakito/sealed-secrets-web/pkg/seal"
+)
+
+// unknownKey is reported for values that none of the private keys can decrypt.
+const unknownKey = "unknown"
+
+// OutdatedSecret is a SealedSecret with values that are not encrypted with the current key.
+type OutdatedSecret struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Fingerprints are the keys the values are encrypted with.
+	Fingerprints []string `json:"fingerprints"`
+}
+
+// OutdatedSecrets is the report of the SealedSecrets to be re-encrypted.
+type OutdatedSecrets struct {
+	Fingerprint string           `json:"fingerprint"`
+	Checked     int              `json:"checked"`
+	Outdated    []OutdatedSecret `json:"outdated"`
+}
+
+// ReEncrypt encrypts the values of a SealedSecret with the current key of the sealing target.
+// The private keys of the break-glass mode are only used if the user is allowed to unseal.
+func (h *Handler) ReEncrypt(c *gin.Context) {
+	outputContentType, outputFormat, done := NegotiateFormat(c)
+	if done {
+		return
+	}
+
+	defer h.observe(c, metrics.OperationReEncrypt)
+	ev, logAudit := startAudit(h.auditor, h.cfg, c, audit.OperationReEncrypt)
+	defer logAudit()
+	ev.Target = h.selectedTarget(c)
+
+	fail := func(code int, err error) {
+		contextNegotiate(c, code, gin.Negotiate{
+			Offered: []string{outputContentType},
+			Data:    gin.H{"error": err.Error()},
+		})
+	}
+
+	sealer, err := h.sealerFor(c)
+	if err != nil {
+		fail(http.StatusNotFound, err)
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		log.Printf("Error reading body in %s: %v\n", Sanitize(c.FullPath()), err)
+		fail(http.StatusInternalServerError, err)
+		return
+	}
+	sealedSecret, err := readSealedSecret(scheme.Codecs.UniversalDecoder(), bytes.NewReader(body))
+	if err != nil {
+		fail(http.StatusUnprocessableEntity, err)
+		return
+	}
+	ev.Namespace = sealedSecret.Namespace
+	ev.Name = sealedSecret.Name
+	scope := sealedSecret.Scope()
+	ev.Scope = scope.String()
+	ev.Keys = slices.Sorted(maps.Keys(sealedSecret.Spec.EncryptedData))
+
+	// the private keys decrypt the values, so they are only used for the users allowed to unseal.
+	// Otherwise, the controller of the target re-encrypts the values.
+	keys := seal.PrivateKeys{}
+	if _, err := h.unsealAllowed(c); err == nil {
+		if keys, err = privateKeys(h.cfg); err != nil {
+			fail(http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	ss, err := sealer.ReEncrypt(c, outputFormat, sealedSecret, keys)
+	if err == nil {
+		ss, err = filterSealedSecret(h.filter, ss, outputFormat)
+	}
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		fail(http.StatusInternalServerError, err)
+		return
+	}
+	c.Data(http.StatusOK, outputContentType, ss)
+}
+
+// UseSealer identifies the current sealing key with the given sealer.
+func (h *SecretsHandler) UseSealer(s seal.Sealer) {
+	h.sealer = s
+}
+
+// OutdatedSecrets reports the SealedSecrets with values that are not encrypted with the current key.
+// The keys of the values are identified with the private keys of the break-glass mode.
+func (h *SecretsHandler) OutdatedSecrets(c *gin.Context) {
+	if h.disableLoadSecrets {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Loading secrets is disabled"})
+		return
+	}
+	if h.sealer == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "The current sealing key is not known"})
+		return
+	}
+	// the private keys decrypt the values, so the report is only available for the users allowed to unseal.
+	if status, err := unsealAllowed(c, h.config); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	keys, err := privateKeys(h.config)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(keys) == 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "The sealing keys can only be identified with the private keys of the unseal configuration",
+		})
+		return
+	}
+
+	rh, status, err := h.forRequest(c)
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	sealedSecrets, err := rh.listSealedSecrets(c)
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	report := OutdatedSecrets{Fingerprint: h.sealer.Fingerprint(), Outdated: []OutdatedSecret{}}
+	for _, ss := range sealedSecrets {
+		if synced := toSecret(ss).Synced; h.config.ShowOnlySyncedSecrets && (synced == nil || !*synced) {
+			continue
+		}
+		report.Checked++
+
+		var fingerprints []string
+		for _, fp := range seal.SealedWith(ss, keys) {
+			if fp == "" {
+				fp = unknownKey
+			}
+			if !slices.Contains(fingerprints, fp) {
+				fingerprints = append(fingerprints, fp)
+			}
+		}
+		if slices.ContainsFunc(fingerprints, func(fp string) bool { return fp != report.Fingerprint }) {
+			slices.Sort(fingerprints)
+			report.Outdated = append(report.Outdated, OutdatedSecret{
+				Namespace:    ss.Namespace,
+				Name:         ss.Name,
+				Fingerprints: fingerprints,
+			})
+		}
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	ssversioned "github.com/bitnami/sealed-secrets/pkg/client/clientset/versioned/fake"
+	"github.com/bitnami/sealed-secrets/pkg/kubeseal"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/bakito/sealed-secrets-web/pkg/auth"
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+	"github.com/bakito/sealed-secrets-web/pkg/mocks/seal"
+	sealpkg "github.com/bakito/sealed-secrets-web/pkg/seal"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReEncrypt", func() {
+	var (
+		recorder *httptest.ResponseRecorder
+		c        *gin.Context
+		sealer   *seal.MockSealer
+		cfg      *config.Config
+	)
+	BeforeEach(func() {
+		gin.SetMode(gin.ReleaseMode)
+		recorder = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(recorder)
+		sealer = seal.NewMockSealer(gomock.NewController(GinkgoT()))
+		cfg = &config.Config{}
+	})
+
+	Context("ReEncrypt", func() {
+		It("should re-encrypt the sealed secret", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/reencrypt", strings.NewReader(`apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: mysecretname
+  namespace: mysecretnamespace
+spec:
+  encryptedData:
+    password: AgBold==
+`))
+			c.Request.Header.Set("Accept", "application/yaml")
+			sealer.EXPECT().ReEncrypt(gomock.Any(), "yaml", gomock.Any(), sealpkg.PrivateKeys{}).
+				DoAndReturn(func(_ any, _ string, ss *v1alpha1.SealedSecret, _ sealpkg.PrivateKeys) ([]byte, error) {
+					Ω(ss.Name).Should(Equal("mysecretname"))
+					return []byte(sealedAsYAML), nil
+				})
+
+			(&Handler{sealer: sealer, cfg: cfg}).ReEncrypt(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Body.String()).Should(Equal(sealedAsYAML))
+		})
+
+		Context("private keys", func() {
+			BeforeEach(func() {
+				key, err := os.ReadFile("../../testdata/key.pem")
+				Ω(err).ShouldNot(HaveOccurred())
+				keyDir := GinkgoT().TempDir()
+				Ω(os.WriteFile(filepath.Join(keyDir, "key.pem"), key, 0o600)).Should(Succeed())
+				cfg.Unseal = config.Unseal{Enabled: true, KeysDir: keyDir, Groups: []string{"recovery"}}
+
+				c.Request, _ = http.NewRequest(http.MethodPost, "/api/reencrypt?target=other", strings.NewReader(
+					"apiVersion: bitnami.com/v1alpha1\nkind: SealedSecret\nmetadata:\n  name: a\n  namespace: b\n"))
+				c.Request.Header.Set("Accept", "application/yaml")
+			})
+			reEncrypt := func() sealpkg.PrivateKeys {
+				var used sealpkg.PrivateKeys
+				sealer.EXPECT().ReEncrypt(gomock.Any(), "yaml", gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, _ string, _ *v1alpha1.SealedSecret, keys sealpkg.PrivateKeys) ([]byte, error) {
+						used = keys
+						return []byte(sealedAsYAML), nil
+					})
+				h := New("", sealpkg.NewRegistry(&sealpkg.Target{Name: "other", Sealer: sealer}), cfg)
+				h.ReEncrypt(c)
+				Ω(recorder.Code).Should(Equal(http.StatusOK))
+				return used
+			}
+
+			It("should use the private keys for the users allowed to unseal", func() {
+				auth.SetUser(c, &auth.User{Name: "jane@example.com", Groups: []string{"recovery"}})
+				Ω(reEncrypt()).Should(HaveLen(1))
+			})
+
+			It("should not use the private keys for other users", func() {
+				auth.SetUser(c, &auth.User{Name: "joe@example.com", Groups: []string{"dev"}})
+				Ω(reEncrypt()).Should(BeEmpty())
+			})
+
+			It("should not use the private keys for unknown users", func() {
+				Ω(reEncrypt()).Should(BeEmpty())
+			})
+		})
+
+		It("should reject an invalid sealed secret", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/reencrypt", strings.NewReader(stringDataAsYAML))
+			c.Request.Header.Set("Accept", "application/json")
+
+			(&Handler{sealer: sealer, cfg: cfg}).ReEncrypt(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+		})
+	})
+
+	Context("OutdatedSecrets", func() {
+		var (
+			h        *SecretsHandler
+			ssClient *ssversioned.Clientset
+			keyFP    string
+		)
+		BeforeEach(func() {
+			f, err := os.Open("../../testdata/cert.pem")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer func() { _ = f.Close() }()
+			pubKey, err := kubeseal.ParseKey(f)
+			Ω(err).ShouldNot(HaveOccurred())
+			ss, err := v1alpha1.NewSealedSecret(scheme.Codecs, pubKey, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "mynamespace"},
+				Data:       map[string][]byte{"password": []byte("s3cret")},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			key, err := os.ReadFile("../../testdata/key.pem")
+			Ω(err).ShouldNot(HaveOccurred())
+			keyDir := GinkgoT().TempDir()
+			Ω(os.WriteFile(filepath.Join(keyDir, "key.pem"), key, 0o600)).Should(Succeed())
+			keys := sealpkg.PrivateKeys{}
+			Ω(keys.Add(key)).Should(Succeed())
+			for fp := range keys {
+				keyFP = fp
+			}
+
+			cfg.Unseal = config.Unseal{Enabled: true, KeysDir: keyDir, Groups: []string{"recovery"}}
+			c.Request, _ = http.NewRequest(http.MethodGet, "/api/secrets/outdated", http.NoBody)
+			auth.SetUser(c, &auth.User{Name: "jane@example.com", Groups: []string{"recovery"}})
+			ssClient = ssversioned.NewSimpleClientset(ss)
+			h = NewHandler(fake.NewClientset().CoreV1(), ssClient.BitnamiV1alpha1(), cfg)
+			h.UseSealer(sealer)
+		})
+
+		It("should report the secrets sealed with another key", func() {
+			sealer.EXPECT().Fingerprint().Return("new")
+			h.OutdatedSecrets(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Body.String()).Should(Equal(`{"fingerprint":"new","checked":1,"outdated":[` +
+				`{"namespace":"mynamespace","name":"mysecret","fingerprints":["` + keyFP + `"]}]}`))
+		})
+
+		It("should use the listed sealed secrets", func() {
+			sealer.EXPECT().Fingerprint().Return("new")
+			h.OutdatedSecrets(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			var verbs []string
+			for _, a := range ssClient.Actions() {
+				verbs = append(verbs, a.GetVerb())
+			}
+			Ω(verbs).Should(Equal([]string{"list"}))
+		})
+
+		It("should not report the secrets sealed with the current key", func() {
+			sealer.EXPECT().Fingerprint().Return(keyFP)
+			h.OutdatedSecrets(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Body.String()).Should(Equal(`{"fingerprint":"` + keyFP + `","checked":1,"outdated":[]}`))
+		})
+
+		It("should require the private keys", func() {
+			cfg.Unseal.KeysDir = ""
+			h.OutdatedSecrets(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusConflict))
+		})
+
+		It("should be forbidden if unsealing is disabled", func() {
+			cfg.Unseal.Enabled = false
+			h.OutdatedSecrets(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusForbidden))
+		})
+
+		It("should be forbidden for users not allowed to unseal", func() {
+			auth.SetUser(c, &auth.User{Name: "joe@example.com", Groups: []string{"dev"}})
+			h.OutdatedSecrets(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusForbidden))
+			Ω(recorder.Body.String()).Should(Equal(`{"error":"user 'joe@example.com' is not allowed to unseal"}`))
+		})
+
+		It("should require a user", func() {
+			c, _ = gin.CreateTestContext(recorder)
+			c.Request, _ = http.NewRequest(http.MethodGet, "/api/secrets/outdated", http.NoBody)
+			h.OutdatedSecrets(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnauthorized))
+		})
+	})
+})
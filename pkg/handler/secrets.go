@@ -7,18 +7,25 @@ import (
 	"net/http"
 	"slices"
 	"strings"
+	"time"
 
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
 	ssclient "github.com/bitnami/sealed-secrets/pkg/client/clientset/versioned/typed/sealedsecrets/v1alpha1"
 	"github.com/gin-gonic/gin"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
+	"github.com/bakito/sealed-secrets-web/pkg/audit"
 	"github.com/bakito/sealed-secrets-web/pkg/config"
+	"github.com/bakito/sealed-secrets-web/pkg/metrics"
+	"github.com/bakito/sealed-secrets-web/pkg/seal"
 )
 
 // BuildClients builds the Kubernetes clients
@@ -26,6 +33,7 @@ import (
 func BuildClients(
 	clientConfig clientcmd.ClientConfig, // Configuration for the Kubernetes connection
 	disableLoadSecrets bool, // Flag to disable loading secrets
+	impersonate *rest.ImpersonationConfig, // Optional user to act as
 ) (typedv1.CoreV1Interface, ssclient.BitnamiV1alpha1Interface, error) {
 	// If loading secrets is disabled, return empty clients
 	if disableLoadSecrets {
@@ -37,6 +45,10 @@ func BuildClients(
 	if err != nil {
 		return nil, nil, err
 	}
+	if impersonate != nil {
+		conf = rest.CopyConfig(conf)
+		conf.Impersonate = *impersonate
+	}
 
 	// Create standard Kubernetes client for core resources (including Secrets)
 	restClient, err := typedv1.NewForConfig(conf)
@@ -55,11 +67,17 @@ func BuildClients(
 
 // SecretsHandler manages all operations for secrets.
 type SecretsHandler struct {
-	coreClient         typedv1.CoreV1Interface           // Client for standard Kubernetes resources
-	ssclient           ssclient.BitnamiV1alpha1Interface // Client for Sealed Secrets
-	disableLoadSecrets bool                              // Flag whether secrets can be loaded
-	includeNamespaces  map[string]bool                   // Map for quick checking if a namespace is included
-	config             *config.Config                    // General configuration
+	coreClient          typedv1.CoreV1Interface           // Client for standard Kubernetes resources
+	ssclient            ssclient.BitnamiV1alpha1Interface // Client for Sealed Secrets
+	disableLoadSecrets  bool                              // Flag whether secrets can be loaded
+	includeNamespaces   map[string]bool                   // Map for quick checking if a namespace is included
+	config              *config.Config                    // General configuration
+	impersonatedClients ClientFactory                     // Creates the clients for the requesting user, if impersonation is enabled
+	auditor             *audit.Logger                     // Records the secret reads, if the audit log is enabled
+	cache               *SecretCache                      // Serves the SealedSecrets from informers, if set
+	sealer              seal.Sealer                       // Identifies the current sealing key, if set
+	metadataClients     MetadataClientFactory             // Lists the metadata of the Secrets, if set
+	impersonate         *rest.ImpersonationConfig         // The requesting user, if impersonation is enabled
 }
 
 // NewHandler creates a new secrets handler.
@@ -148,6 +166,11 @@ func (h *SecretsHandler) NamespacesMatch(namespaces []string) map[string]bool {
 
 // list returns a list of all secrets that match the filter criteria.
 func (h *SecretsHandler) list(ctx context.Context) ([]Secret, error) {
+	return h.listSelected(ctx, labels.Everything())
+}
+
+// listSelected returns a list of the secrets that match the filter criteria and the label selector.
+func (h *SecretsHandler) listSelected(ctx context.Context, selector labels.Selector) ([]Secret, error) {
 	var secrets []Secret
 
 	// If loading secrets is disabled, return an empty list
@@ -155,41 +178,19 @@ func (h *SecretsHandler) list(ctx context.Context) ([]Secret, error) {
 		return secrets, nil
 	}
 
-	// If namespace filters are defined (inclusion or exclusion)
-	if len(h.config.ExcludeNamespaces) > 0 || len(h.config.IncludeNamespaces) > 0 {
-		// Exclusion always takes precedence over inclusion
-		var nsNameList []string
-
-		// If no inclusion rules are defined, gather all available namespaces
-		if len(h.config.IncludeNamespaces) == 0 || h.config.UseRegex {
-			nsList, err := h.coreClient.Namespaces().List(ctx, metav1.ListOptions{})
-			if err != nil {
-				return nil, err
-			}
-			for _, namespace := range nsList.Items {
-				nsNameList = append(nsNameList, namespace.Name)
-			}
-		} else {
-			nsNameList = h.config.IncludeNamespaces
-		}
+	// Serve the secrets from the informer cache, if available
+	if h.cache != nil {
+		return h.listFromCache(selector), nil
+	}
 
-		matchedNamespaces := h.NamespacesMatch(nsNameList)
+	namespaces, err := h.allowedNamespaceList(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-		// Get secrets for all matching namespaces
-		for ns, v := range matchedNamespaces {
-			if !v {
-				continue
-			}
-			list, err := h.listForNamespace(ctx, ns)
-			if err != nil {
-				return nil, err
-			}
-			secrets = append(secrets, list...)
-		}
-	} else {
-		// If no filters are specified, list all secrets in all namespaces
-		// Empty string ("") means "all namespaces"
-		list, err := h.listForNamespace(ctx, "")
+	// Get secrets for all matching namespaces
+	for _, ns := range namespaces {
+		list, err := h.listForNamespace(ctx, ns, selector)
 		if err != nil {
 			return nil, err
 		}
@@ -208,36 +209,58 @@ func (h *SecretsHandler) list(ctx context.Context) ([]Secret, error) {
 	return secrets, nil
 }
 
+// allowedNamespaceList returns the namespaces to list the secrets from.
+// If no filters are specified, the list contains only the empty string, which means "all namespaces".
+func (h *SecretsHandler) allowedNamespaceList(ctx context.Context) ([]string, error) {
+	// If no namespace filters are defined (inclusion or exclusion)
+	if len(h.config.ExcludeNamespaces) == 0 && len(h.config.IncludeNamespaces) == 0 {
+		return []string{metav1.NamespaceAll}, nil
+	}
+
+	// Exclusion always takes precedence over inclusion
+	var nsNameList []string
+
+	// If no inclusion rules are defined, gather all available namespaces
+	if len(h.config.IncludeNamespaces) == 0 || h.config.UseRegex {
+		start := time.Now()
+		nsList, err := h.coreClient.Namespaces().List(ctx, metav1.ListOptions{})
+		metrics.ObserveKubernetesRequest("list_namespaces", time.Since(start))
+		if err != nil {
+			return nil, err
+		}
+		for _, namespace := range nsList.Items {
+			nsNameList = append(nsNameList, namespace.Name)
+		}
+	} else {
+		nsNameList = h.config.IncludeNamespaces
+	}
+
+	var namespaces []string
+	for ns, v := range h.NamespacesMatch(nsNameList) {
+		if v {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	slices.Sort(namespaces)
+	return namespaces, nil
+}
+
 // listForNamespace retrieves all Sealed Secrets in a specific namespace.
-func (h *SecretsHandler) listForNamespace(ctx context.Context, ns string) ([]Secret, error) {
+func (h *SecretsHandler) listForNamespace(ctx context.Context, ns string, selector labels.Selector) ([]Secret, error) {
 	var secrets []Secret
 
 	// API call to retrieve all SealedSecrets in the specified namespace
 	// Empty string ("") means "all namespaces"
-	ssList, err := h.ssclient.SealedSecrets(ns).List(ctx, metav1.ListOptions{})
+	start := time.Now()
+	ssList, err := h.ssclient.SealedSecrets(ns).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	metrics.ObserveKubernetesRequest("list_sealedsecrets", time.Since(start))
 	if err != nil {
 		return nil, err
 	}
 
 	// Convert SealedSecrets to the simpler Secret structure
-	for _, item := range ssList.Items {
-		secret := Secret{
-			Namespace: item.Namespace,
-			Name:      item.Name,
-		}
-
-		// Extract synced status from conditions
-		if item.Status != nil && len(item.Status.Conditions) > 0 {
-			// Find the "Synced" condition
-			for _, condition := range item.Status.Conditions {
-				if condition.Type == "Synced" {
-					synced := condition.Status == corev1.ConditionTrue
-					secret.Synced = &synced
-					secret.Message = condition.Message
-					break
-				}
-			}
-		}
+	for i := range ssList.Items {
+		secret := toSecret(&ssList.Items[i])
 
 		// Only add secrets that match the filter criteria
 		if h.config.ShowOnlySyncedSecrets {
@@ -253,6 +276,134 @@ func (h *SecretsHandler) listForNamespace(ctx context.Context, ns string) ([]Sec
 	return secrets, nil
 }
 
+// toSecret converts the SealedSecret into the simpler Secret structure.
+func toSecret(item *v1alpha1.SealedSecret) Secret {
+	secret := Secret{
+		Namespace: item.Namespace,
+		Name:      item.Name,
+	}
+
+	// Extract synced status from conditions
+	if item.Status != nil && len(item.Status.Conditions) > 0 {
+		// Find the "Synced" condition
+		for _, condition := range item.Status.Conditions {
+			if condition.Type == "Synced" {
+				synced := condition.Status == corev1.ConditionTrue
+				secret.Synced = &synced
+				secret.Message = condition.Message
+				break
+			}
+		}
+	}
+	return secret
+}
+
+// syncStates counts the SealedSecrets by synced state.
+type syncStates struct {
+	synced    int
+	notSynced int
+	unknown   int
+}
+
+func (s *syncStates) add(synced *bool) {
+	switch {
+	case synced == nil:
+		s.unknown++
+	case *synced:
+		s.synced++
+	default:
+		s.notSynced++
+	}
+}
+
+// listSealedSecrets returns the SealedSecrets of the allowed namespaces, sorted by namespace and name.
+// They are read from the cache or listed with the client of the handler.
+func (h *SecretsHandler) listSealedSecrets(ctx context.Context) ([]*v1alpha1.SealedSecret, error) {
+	var items []*v1alpha1.SealedSecret
+	if h.cache != nil {
+		items = h.cache.List()
+	} else {
+		namespaces, err := h.allowedNamespaceList(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range namespaces {
+			start := time.Now()
+			list, err := h.ssclient.SealedSecrets(ns).List(ctx, metav1.ListOptions{})
+			metrics.ObserveKubernetesRequest("list_sealedsecrets", time.Since(start))
+			if err != nil {
+				return nil, err
+			}
+			for i := range list.Items {
+				items = append(items, &list.Items[i])
+			}
+		}
+	}
+
+	var namespaces []string
+	for _, item := range items {
+		namespaces = append(namespaces, item.Namespace)
+	}
+	allowed := h.allowedNamespaces(namespaces)
+
+	result := []*v1alpha1.SealedSecret{}
+	for _, item := range items {
+		if allowed(item.Namespace) {
+			result = append(result, item)
+		}
+	}
+	slices.SortFunc(result, func(i, j *v1alpha1.SealedSecret) int {
+		if cmp := strings.Compare(i.Namespace, j.Namespace); cmp != 0 {
+			return cmp
+		}
+		return strings.Compare(i.Name, j.Name)
+	})
+	return result, nil
+}
+
+// countSyncStates counts all SealedSecrets of the allowed namespaces by synced state. They are read from the cache
+// or, without cache, listed with the client of the service account, never with the view of a requesting user.
+func (h *SecretsHandler) countSyncStates(ctx context.Context) (*syncStates, error) {
+	items, err := h.listSealedSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	states := &syncStates{}
+	for _, item := range items {
+		states.add(toSecret(item).Synced)
+	}
+	return states, nil
+}
+
+// updateSyncStates sets the metric of the SealedSecrets by synced state.
+func (h *SecretsHandler) updateSyncStates(ctx context.Context) {
+	states, err := h.countSyncStates(ctx)
+	if err != nil {
+		log.Printf("Could not count the sealed secrets by synced state: %v\n", err)
+		return
+	}
+	metrics.SetSealedSecrets(states.synced, states.notSynced, states.unknown)
+}
+
+// ReportSyncStates updates the metric of the SealedSecrets by synced state in the given interval, until the context
+// is done. With a cache, the metric is updated on each change of the cache instead.
+func (h *SecretsHandler) ReportSyncStates(ctx context.Context, interval time.Duration) {
+	if h.disableLoadSecrets || h.cache != nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.updateSyncStates(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // GetSecret returns a single secret by namespace and name.
 func (h *SecretsHandler) GetSecret(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
 	// If loading secrets is disabled, return null
@@ -261,33 +412,47 @@ func (h *SecretsHandler) GetSecret(ctx context.Context, namespace, name string)
 	}
 
 	// Check if the namespace is allowed according to the filter rules
-	if len(h.config.ExcludeNamespaces) > 0 || len(h.config.IncludeNamespaces) > 0 {
-		namespaces := h.NamespacesMatch([]string{namespace})
-		if !namespaces[namespace] {
-			return nil, fmt.Errorf("namespace '%s' is not allowed", namespace)
-		}
+	if err := h.namespaceAllowed(namespace); err != nil {
+		return nil, err
 	}
 
 	// Retrieve the secret from the Kubernetes cluster
-	secret, err := h.coreClient.Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	secret, err := h.liveSecret(ctx, namespace, name)
 	if err != nil {
 		return nil, err
 	}
 
-	// Clean up secret metadata (remove unnecessary fields)
+	// Clean up secret metadata (remove runtime and the configured fields)
+	secret, err = filterObject(secret, runtimeFields, h.config.FieldFilter)
+	if err != nil {
+		return nil, err
+	}
 	secret.TypeMeta = metav1.TypeMeta{
 		APIVersion: "v1",
 		Kind:       "Secret",
 	}
-	secret.ManagedFields = nil
-	secret.OwnerReferences = nil
-	secret.CreationTimestamp = metav1.Time{}
-	secret.ResourceVersion = ""
-	secret.UID = ""
 
 	return secret, nil
 }
 
+// namespaceAllowed returns an error if the namespace is not allowed according to the filter rules.
+func (h *SecretsHandler) namespaceAllowed(namespace string) error {
+	if len(h.config.ExcludeNamespaces) > 0 || len(h.config.IncludeNamespaces) > 0 {
+		if !h.NamespacesMatch([]string{namespace})[namespace] {
+			return fmt.Errorf("namespace '%s' is not allowed", namespace)
+		}
+	}
+	return nil
+}
+
+// liveSecret retrieves the secret from the cluster as it is, including its owner references.
+func (h *SecretsHandler) liveSecret(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
+	start := time.Now()
+	secret, err := h.coreClient.Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	metrics.ObserveKubernetesRequest("get_secret", time.Since(start))
+	return secret, err
+}
+
 // AllSecrets is an HTTP handler that returns a list of all available secrets.
 func (h *SecretsHandler) AllSecrets(c *gin.Context) {
 	// If loading secrets is disabled, return an error
@@ -296,8 +461,23 @@ func (h *SecretsHandler) AllSecrets(c *gin.Context) {
 		return
 	}
 
+	// Use the clients of the requesting user, if impersonation is enabled
+	rh, status, err := h.forRequest(c)
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Parse the pagination and filter parameters
+	query, err := parseListQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Retrieve secrets
-	sec, err := h.list(c)
+	sec, err := rh.listSelected(c, query.selector)
 	if err != nil {
 		// Log error and return it to the client
 		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
@@ -305,8 +485,14 @@ func (h *SecretsHandler) AllSecrets(c *gin.Context) {
 		return
 	}
 
-	// Successful response with the list of secrets
-	c.JSON(http.StatusOK, gin.H{"secrets": sec})
+	// Flag the drift of the secrets of the page, if requested
+	page := query.page(sec)
+	if query.drift {
+		rh.flagDrift(c, page.Secrets)
+	}
+
+	// Successful response with the requested page of secrets
+	c.JSON(http.StatusOK, page)
 }
 
 // Secret is an HTTP handler that returns a single secret.
@@ -327,14 +513,31 @@ func (h *SecretsHandler) Secret(c *gin.Context) {
 	namespace := Sanitize(c.Param("namespace"))
 	name := Sanitize(c.Param("name"))
 
+	// Record who read which secret
+	ev, logAudit := startAudit(h.auditor, h.config, c, audit.OperationSecretRead)
+	defer logAudit()
+	ev.Namespace = namespace
+	ev.Name = name
+
+	// Use the clients of the requesting user, if impersonation is enabled
+	rh, status, err := h.forRequest(c)
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Retrieve the secret
-	secret, err := h.GetSecret(c, namespace, name)
+	secret, err := rh.GetSecret(c, namespace, name)
 	if err != nil {
 		// Log error and return it to the client
 		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if secret != nil {
+		auditSecret(ev, secret)
+	}
 
 	// Encode the secret in the desired format
 	encode, err := encodeSecret(secret, outputFormat)
@@ -351,6 +554,11 @@ func (h *SecretsHandler) Secret(c *gin.Context) {
 
 // encodeSecret encodes a Secret object into the specified format (JSON or YAML).
 func encodeSecret(secret *corev1.Secret, outputFormat string) ([]byte, error) {
+	return encodeObject(secret, schema.GroupVersion{Group: "", Version: "v1"}, outputFormat)
+}
+
+// encodeObject encodes an object of the given api version into the specified format (JSON or YAML).
+func encodeObject(obj runtime.Object, gv schema.GroupVersion, outputFormat string) ([]byte, error) {
 	var contentType string
 
 	// Determine content type based on the desired output format
@@ -376,10 +584,10 @@ func encodeSecret(secret *corev1.Secret, outputFormat string) ([]byte, error) {
 	}
 
 	// Create encoder for the API version
-	encoder := scheme.Codecs.EncoderForVersion(prettyEncoder, schema.GroupVersion{Group: "", Version: "v1"})
+	encoder := scheme.Codecs.EncoderForVersion(prettyEncoder, gv)
 
-	// Encode and return the secret
-	return runtime.Encode(encoder, secret)
+	// Encode and return the object
+	return runtime.Encode(encoder, obj)
 }
 
 // Secret represents the basic data of a Kubernetes Secret.
@@ -388,4 +596,5 @@ type Secret struct {
 	Name      string `json:"name"              yaml:"name"`              // Name of the secret
 	Synced    *bool  `json:"synced,omitempty"  yaml:"synced,omitempty"`  // Whether the SealedSecret has been successfully synced (nil if status unknown)
 	Message   string `json:"message,omitempty" yaml:"message,omitempty"` // Status message from the SealedSecret condition
+	Drift     *bool  `json:"drift,omitempty"   yaml:"drift,omitempty"`   // Whether the Secret drifted from the SealedSecret (nil if not checked)
 }
@@ -346,6 +346,29 @@ var _ = Describe("SecretsHandler", func() {
 				Ω(*result[0].Synced).Should(BeTrue())
 			})
 		})
+
+		Context("countSyncStates", func() {
+			It("should count all secrets of the allowed namespaces by synced state", func() {
+				cfg.ExcludeNamespaces = []string{"ns3"}
+				cfg.ShowOnlySyncedSecrets = true
+				fakeClient = fake.NewClientset(
+					&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns1"}},
+					&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns2"}},
+					&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns3"}},
+				)
+				setupSealedSecretsReactor(fakeSSClient, []ssv1alpha1.SealedSecret{
+					createSealedSecretWithStatus("synced-secret", "ns1", true, ""),
+					createSealedSecretWithStatus("failed-secret", "ns2", false, "decryption failed"),
+					{ObjectMeta: metav1.ObjectMeta{Name: "no-status", Namespace: "ns2"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "excluded", Namespace: "ns3"}},
+				})
+
+				handler = NewHandler(fakeClient.CoreV1(), fakeSSClient, cfg)
+				states, err := handler.countSyncStates(context.Background())
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(states).Should(Equal(&syncStates{synced: 1, notSynced: 1, unknown: 1}))
+			})
+		})
 	})
 })
 
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/bakito/sealed-secrets-web/pkg/policy"
+)
+
+// FieldError is a validation error of a secret field. It contains no secret values.
+type FieldError struct {
+	Field  string `json:"field"`
+	Type   string `json:"type"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// SecretInvalidError is returned if a secret does not pass the validation.
+type SecretInvalidError struct {
+	Fields []FieldError
+}
+
+func (e *SecretInvalidError) Error() string {
+	if len(e.Fields) == 1 {
+		return fmt.Sprintf("the secret is invalid: %s: %s", e.Fields[0].Field, e.Fields[0].Type)
+	}
+	return fmt.Sprintf("the secret is invalid: %d errors", len(e.Fields))
+}
+
+// errorResponse returns the error response, including the field errors of an invalid secret
+// or the violations of the policy.
+func errorResponse(err error) gin.H {
+	var invalid *SecretInvalidError
+	if errors.As(err, &invalid) {
+		return gin.H{"error": invalid.Error(), "fields": invalid.Fields}
+	}
+	var denied *policy.DeniedError
+	if errors.As(err, &denied) {
+		return gin.H{"error": denied.Error(), "violations": denied.Violations}
+	}
+	return gin.H{"error": err.Error()}
+}
+
+// validateSecret checks the secret as the api server would: the name and namespace, the key names, the size
+// and the required keys of the built-in secret types. A nil value is unknown, e.g. an already sealed value of
+// a merge, so only its key is checked.
+func validateSecret(secret *corev1.Secret) error {
+	var errs field.ErrorList
+	meta := field.NewPath("metadata")
+	if secret.Name != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(secret.Name) {
+			errs = append(errs, field.Invalid(meta.Child("name"), secret.Name, msg))
+		}
+	}
+	if secret.Namespace != "" {
+		for _, msg := range validation.IsDNS1123Label(secret.Namespace) {
+			errs = append(errs, field.Invalid(meta.Child("namespace"), secret.Namespace, msg))
+		}
+	}
+
+	// the keys of stringData are merged into data by the api server
+	data := make(map[string][]byte)
+	keyPath := make(map[string]*field.Path)
+	for k, v := range secret.Data {
+		data[k] = v
+		keyPath[k] = field.NewPath("data").Key(k)
+	}
+	for k, v := range secret.StringData {
+		data[k] = []byte(v)
+		keyPath[k] = field.NewPath("stringData").Key(k)
+	}
+
+	var size int
+	for _, k := range slices.Sorted(maps.Keys(data)) {
+		v := data[k]
+		for _, msg := range validation.IsConfigMapKey(k) {
+			errs = append(errs, field.Invalid(keyPath[k], k, msg))
+		}
+		size += len(v)
+	}
+	if size > corev1.MaxSecretSize {
+		errs = append(errs, field.TooLong(field.NewPath("data"), "", corev1.MaxSecretSize))
+	}
+
+	errs = append(errs, validateSecretType(secret, data)...)
+
+	if len(errs) == 0 {
+		return nil
+	}
+	invalid := &SecretInvalidError{}
+	for _, e := range errs {
+		invalid.Fields = append(invalid.Fields, FieldError{Field: e.Field, Type: e.Type.String(), Detail: e.Detail})
+	}
+	return invalid
+}
+
+// validateSecretType checks the required keys of the built-in secret types.
+func validateSecretType(secret *corev1.Secret, data map[string][]byte) field.ErrorList {
+	var errs field.ErrorList
+	dataPath := field.NewPath("data")
+
+	required := func(keys ...string) {
+		for _, k := range keys {
+			if _, ok := data[k]; !ok {
+				errs = append(errs, field.Required(dataPath.Key(k), fmt.Sprintf("required for type %s", secret.Type)))
+			}
+		}
+	}
+	validJSON := func(key string) {
+		if v, ok := data[key]; ok && v != nil && !json.Valid(v) {
+			errs = append(errs, field.Invalid(dataPath.Key(key), "", "must be valid json"))
+		}
+	}
+
+	switch secret.Type {
+	case corev1.SecretTypeTLS:
+		required(corev1.TLSCertKey, corev1.TLSPrivateKeyKey)
+	case corev1.SecretTypeDockerConfigJson:
+		required(corev1.DockerConfigJsonKey)
+		validJSON(corev1.DockerConfigJsonKey)
+	case corev1.SecretTypeDockercfg:
+		required(corev1.DockerConfigKey)
+		validJSON(corev1.DockerConfigKey)
+	case corev1.SecretTypeBasicAuth:
+		_, user := data[corev1.BasicAuthUsernameKey]
+		_, password := data[corev1.BasicAuthPasswordKey]
+		if !user && !password {
+			errs = append(errs, field.Required(dataPath.Key(corev1.BasicAuthUsernameKey),
+				"either username or password is required for type "+string(secret.Type)))
+		}
+	case corev1.SecretTypeSSHAuth:
+		required(corev1.SSHAuthPrivateKey)
+	case corev1.SecretTypeServiceAccountToken:
+		if secret.Annotations[corev1.ServiceAccountNameKey] == "" {
+			errs = append(errs, field.Required(
+				field.NewPath("metadata", "annotations").Key(corev1.ServiceAccountNameKey),
+				fmt.Sprintf("required for type %s", secret.Type),
+			))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bakito/sealed-secrets-web/pkg/mocks/seal"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Secret validation", func() {
+	Context("validateSecret", func() {
+		fields := func(err error) []string {
+			Ω(err).Should(BeAssignableToTypeOf(&SecretInvalidError{}))
+			var result []string
+			for _, f := range err.(*SecretInvalidError).Fields {
+				result = append(result, f.Field+": "+f.Type)
+			}
+			return result
+		}
+
+		It("should accept a valid secret", func() {
+			Ω(validateSecret(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "my.secret", Namespace: "my-ns"},
+				Type:       corev1.SecretTypeTLS,
+				Data:       map[string][]byte{"tls.crt": []byte("crt")},
+				StringData: map[string]string{"tls.key": "key"},
+			})).Should(Succeed())
+		})
+
+		It("should require the keys of the secret type", func() {
+			Ω(fields(validateSecret(&corev1.Secret{
+				Type: corev1.SecretTypeTLS,
+				Data: map[string][]byte{"tls.crt": []byte("crt")},
+			}))).Should(Equal([]string{"data[tls.key]: Required value"}))
+
+			Ω(fields(validateSecret(&corev1.Secret{Type: corev1.SecretTypeBasicAuth}))).
+				Should(Equal([]string{"data[username]: Required value"}))
+			Ω(fields(validateSecret(&corev1.Secret{Type: corev1.SecretTypeSSHAuth}))).
+				Should(Equal([]string{"data[ssh-privatekey]: Required value"}))
+			Ω(fields(validateSecret(&corev1.Secret{Type: corev1.SecretTypeServiceAccountToken}))).
+				Should(Equal([]string{"metadata.annotations[kubernetes.io/service-account.name]: Required value"}))
+		})
+
+		It("should require valid docker config json", func() {
+			Ω(fields(validateSecret(&corev1.Secret{
+				Type:       corev1.SecretTypeDockerConfigJson,
+				StringData: map[string]string{".dockerconfigjson": "{"},
+			}))).Should(Equal([]string{"data[.dockerconfigjson]: Invalid value"}))
+		})
+
+		It("should reject invalid names and keys", func() {
+			Ω(fields(validateSecret(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "My_Secret", Namespace: "my.ns"},
+				StringData: map[string]string{"my key": "value"},
+			}))).Should(ConsistOf(
+				"metadata.name: Invalid value",
+				"metadata.namespace: Invalid value",
+				"stringData[my key]: Invalid value",
+			))
+		})
+
+		It("should reject secrets larger than 1 MiB", func() {
+			Ω(fields(validateSecret(&corev1.Secret{
+				Data: map[string][]byte{
+					"a": bytes.Repeat([]byte("a"), corev1.MaxSecretSize/2),
+					"b": bytes.Repeat([]byte("b"), corev1.MaxSecretSize/2+1),
+				},
+			}))).Should(Equal([]string{"data: Too long"}))
+		})
+	})
+
+	Context("handlers", func() {
+		const invalidTLS = `apiVersion: v1
+kind: Secret
+metadata:
+  name: mysecret
+  namespace: mynamespace
+type: kubernetes.io/tls
+stringData:
+  tls.crt: crt
+  my key: value
+`
+		var (
+			recorder *httptest.ResponseRecorder
+			c        *gin.Context
+		)
+		BeforeEach(func() {
+			gin.SetMode(gin.ReleaseMode)
+			recorder = httptest.NewRecorder()
+			c, _ = gin.CreateTestContext(recorder)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/kubeseal", strings.NewReader(invalidTLS))
+			c.Request.Header.Set("Content-Type", "application/yaml")
+			c.Request.Header.Set("Accept", "application/json")
+		})
+
+		It("should not seal an invalid secret", func() {
+			h := &Handler{sealer: seal.NewMockSealer(gomock.NewController(GinkgoT()))}
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+			Ω(recorder.Body.String()).Should(ContainSubstring(`"error":"the secret is invalid: 2 errors"`))
+			Ω(recorder.Body.String()).Should(ContainSubstring(`{"field":"data[tls.key]","type":"Required value"`))
+			Ω(recorder.Body.String()).Should(ContainSubstring(`{"field":"stringData[my key]","type":"Invalid value"`))
+		})
+
+		It("should report the invalid documents of a multi-document input", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/kubeseal",
+				strings.NewReader(invalidTLS+"---\n"+invalidTLS))
+			c.Request.Header.Set("Accept", "application/json")
+			h := &Handler{sealer: seal.NewMockSealer(gomock.NewController(GinkgoT()))}
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+			Ω(recorder.Body.String()).Should(ContainSubstring(`"error":"2 of 2 documents could not be sealed"`))
+			Ω(recorder.Body.String()).Should(ContainSubstring(`"fields":[{"field":"stringData[my key]"`))
+		})
+
+		It("should not dencode an invalid secret", func() {
+			(&Handler{}).Dencode(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+			Ω(recorder.Body.String()).Should(ContainSubstring(`{"field":"data[tls.key]","type":"Required value"`))
+		})
+	})
+})
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bakito/sealed-secrets-web/pkg/metrics"
+	"github.com/bakito/sealed-secrets-web/pkg/seal"
+)
+
+const (
+	// HeaderSealingTarget is used to select the sealing target of a request.
+	HeaderSealingTarget = "X-Sealing-Target"
+	queryTarget         = "target"
+)
+
+// Targets returns the list of the available sealing targets.
+func (h *Handler) Targets(c *gin.Context) {
+	targets := []target{}
+	for i, t := range h.registry.Targets() {
+		targets = append(targets, target{
+			Name:     t.Name,
+			Default:  i == 0,
+			Validate: t.Config.CanVerify(),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"targets": targets})
+}
+
+// targetName returns the name of the sealing target selected by query parameter or header.
+func targetName(c *gin.Context) string {
+	name := c.Query(queryTarget)
+	if name == "" {
+		name = c.GetHeader(HeaderSealingTarget)
+	}
+	return name
+}
+
+// selectedTarget returns the name of the selected target, or the name of the default target if none is selected.
+func (h *Handler) selectedTarget(c *gin.Context) string {
+	if name := targetName(c); name != "" {
+		return name
+	}
+	if targets := h.registry.Targets(); len(targets) > 0 {
+		return targets[0].Name
+	}
+	return ""
+}
+
+// observe counts the operation with the status of the response and is meant to be deferred.
+func (h *Handler) observe(c *gin.Context, operation string) {
+	metrics.ObserveOperation(operation, h.metricsTarget(c), c.Writer.Status())
+}
+
+// metricsTarget returns the name of the selected target as metrics label. Unknown targets are counted as one,
+// to keep the cardinality of the label bounded.
+func (h *Handler) metricsTarget(c *gin.Context) string {
+	name := h.selectedTarget(c)
+	if targetName(c) == "" {
+		return name
+	}
+	if _, ok := h.registry.Get(name); !ok {
+		return metrics.UnknownTarget
+	}
+	return name
+}
+
+// sealerFor returns the sealer of the selected target, or the default sealer if no target is selected.
+func (h *Handler) sealerFor(c *gin.Context) (seal.Sealer, error) {
+	name := targetName(c)
+	if name == "" {
+		return h.sealer, nil
+	}
+	t, ok := h.registry.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown sealing target '%s'", name)
+	}
+	return t.Sealer, nil
+}
+
+// target represents a sealing target in the target list.
+type target struct {
+	Name     string `json:"name"`
+	Default  bool   `json:"default"`
+	Validate bool   `json:"validate"`
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+	"github.com/bakito/sealed-secrets-web/pkg/metrics"
+	mockseal "github.com/bakito/sealed-secrets-web/pkg/mocks/seal"
+	"github.com/bakito/sealed-secrets-web/pkg/seal"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Handler ", func() {
+	Context("Targets", func() {
+		var (
+			recorder *httptest.ResponseRecorder
+			c        *gin.Context
+			mock     *gomock.Controller
+			dev      *mockseal.MockSealer
+			prod     *mockseal.MockSealer
+			h        *Handler
+		)
+		BeforeEach(func() {
+			gin.SetMode(gin.ReleaseMode)
+			recorder = httptest.NewRecorder()
+			c, _ = gin.CreateTestContext(recorder)
+			mock = gomock.NewController(GinkgoT())
+			dev = mockseal.NewMockSealer(mock)
+			prod = mockseal.NewMockSealer(mock)
+			cfg := &config.Config{}
+			cfg.SealedSecrets.Name = "dev"
+			h = New("", seal.NewRegistry(
+				&seal.Target{Name: "dev", Sealer: dev},
+				&seal.Target{
+					Name:   "prod",
+					Config: config.SealedSecrets{CertURL: "http://sealed-secrets/v1/cert.pem"},
+					Sealer: prod,
+				},
+			), cfg)
+		})
+
+		It("should list the targets", func() {
+			c.Request, _ = http.NewRequest(http.MethodGet, "/v1/targets", http.NoBody)
+			h.Targets(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Body.String()).Should(Equal(
+				`{"targets":[{"name":"dev","default":true,"validate":true},{"name":"prod","default":false,"validate":false}]}`,
+			))
+		})
+
+		It("should use the default target", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/v1/kubeseal", bytes.NewReader([]byte(stringDataAsYAML)))
+			c.Request.Header.Set("Content-Type", "application/yaml")
+			c.Request.Header.Set("Accept", "application/yaml")
+
+			dev.EXPECT().Seal("yaml", v1alpha1.StrictScope, gomock.Any()).Return([]byte(sealedAsYAML), nil)
+
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+		})
+
+		It("should use the target selected by query parameter", func() {
+			c.Request, _ = http.NewRequest(
+				http.MethodPost,
+				"/v1/kubeseal?target=prod",
+				bytes.NewReader([]byte(stringDataAsYAML)),
+			)
+			c.Request.Header.Set("Content-Type", "application/yaml")
+			c.Request.Header.Set("Accept", "application/yaml")
+
+			prod.EXPECT().Seal("yaml", v1alpha1.StrictScope, gomock.Any()).Return([]byte(sealedAsYAML), nil)
+
+			h.KubeSeal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+		})
+
+		It("should use the target selected by header", func() {
+			c.Request, _ = http.NewRequest(http.MethodGet, "/v1/certificate", http.NoBody)
+			c.Request.Header.Set(HeaderSealingTarget, "prod")
+
+			prod.EXPECT().Certificate(gomock.Any()).Return([]byte(validCertificate), nil)
+
+			h.Certificate(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+		})
+
+		It("should return 404 for an unknown target", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/v1/raw?target=foo", bytes.NewReader([]byte(rawData)))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			h.Raw(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusNotFound))
+			Ω(recorder.Body.String()).Should(Equal(`{"error":"unknown sealing target 'foo'"}`))
+			Ω(h.metricsTarget(c)).Should(Equal(metrics.UnknownTarget))
+		})
+
+		It("should use the selected target as metrics label", func() {
+			c.Request, _ = http.NewRequest(http.MethodPost, "/v1/raw?target=prod", http.NoBody)
+			Ω(h.metricsTarget(c)).Should(Equal("prod"))
+
+			c, _ = gin.CreateTestContext(recorder)
+			c.Request, _ = http.NewRequest(http.MethodPost, "/v1/raw", http.NoBody)
+			Ω(h.metricsTarget(c)).Should(Equal("dev"))
+		})
+
+		It("should validate with a target using a cert URL", func() {
+			c.Request, _ = http.NewRequest(
+				http.MethodPost,
+				"/v1/validate?target=prod",
+				bytes.NewReader([]byte(stringDataAsYAML)),
+			)
+
+			prod.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+
+			h.Validate(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Body.String()).Should(Equal("OK"))
+		})
+	})
+})
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/bakito/sealed-secrets-web/pkg/metrics"
+)
+
+var secretsResource = corev1.SchemeGroupVersion.WithResource("secrets")
+
+// MetadataClientFactory creates the Kubernetes client for the metadata of resources, acting as the given user
+// or as the ServiceAccount if nil.
+type MetadataClientFactory func(impersonate *rest.ImpersonationConfig) (metadata.Interface, error)
+
+// MetadataClients returns a MetadataClientFactory building the clients from the given configuration.
+func MetadataClients(clientConfig clientcmd.ClientConfig) MetadataClientFactory {
+	return func(impersonate *rest.ImpersonationConfig) (metadata.Interface, error) {
+		conf, err := clientConfig.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		if impersonate != nil {
+			conf = rest.CopyConfig(conf)
+			conf.Impersonate = *impersonate
+		}
+		return metadata.NewForConfig(conf)
+	}
+}
+
+// UseMetadataClients enables the report of the unmanaged secrets, listing only the metadata of the Secrets.
+func (h *SecretsHandler) UseMetadataClients(factory MetadataClientFactory) {
+	h.metadataClients = factory
+}
+
+// UnmanagedSecret is a Secret that is not owned by a SealedSecret.
+type UnmanagedSecret struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// UnmanagedSecrets is an HTTP handler that lists the Secrets of the allowed namespaces that are not owned by
+// a SealedSecret. Secrets of the configured skip types are not listed.
+// Only the metadata of the Secrets is listed, so their values are never loaded.
+func (h *SecretsHandler) UnmanagedSecrets(c *gin.Context) {
+	if h.disableLoadSecrets {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Loading secrets is disabled"})
+		return
+	}
+
+	// Use the clients of the requesting user, if impersonation is enabled
+	rh, status, err := h.forRequest(c)
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if rh.metadataClients == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Listing unmanaged secrets is disabled"})
+		return
+	}
+	metadataClient, err := rh.metadataClients(rh.impersonate)
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	secrets, err := rh.listUnmanaged(c, metadataClient)
+	if err != nil {
+		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+		c.JSON(apiErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"secrets": secrets})
+}
+
+// listUnmanaged returns the Secrets of the allowed namespaces without a SealedSecret owner, sorted by namespace and name.
+func (h *SecretsHandler) listUnmanaged(
+	ctx context.Context,
+	metadataClient metadata.Interface,
+) ([]UnmanagedSecret, error) {
+	namespaces, err := h.allowedNamespaceList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the type is not part of the metadata, the skipped types are filtered by the api server
+	var selectors []fields.Selector
+	for _, t := range h.config.UnmanagedSecrets.SkipTypes {
+		selectors = append(selectors, fields.OneTermNotEqualSelector("type", t))
+	}
+	opts := metav1.ListOptions{FieldSelector: fields.AndSelectors(selectors...).String()}
+
+	secrets := []UnmanagedSecret{}
+	for _, ns := range namespaces {
+		start := time.Now()
+		list, err := metadataClient.Resource(secretsResource).Namespace(ns).List(ctx, opts)
+		metrics.ObserveKubernetesRequest("list_secrets_metadata", time.Since(start))
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			s := &list.Items[i]
+			if ownedBySealedSecret(s.OwnerReferences) {
+				continue
+			}
+			secrets = append(secrets, UnmanagedSecret{Namespace: s.Namespace, Name: s.Name})
+		}
+	}
+
+	slices.SortFunc(secrets, func(i, j UnmanagedSecret) int {
+		if cmp := strings.Compare(i.Namespace, j.Namespace); cmp != 0 {
+			return cmp
+		}
+		return strings.Compare(i.Name, j.Name)
+	})
+	return secrets, nil
+}
+
+// ownedBySealedSecret checks if one of the owners is a SealedSecret.
+func ownedBySealedSecret(owners []metav1.OwnerReference) bool {
+	return slices.ContainsFunc(owners, func(ref metav1.OwnerReference) bool {
+		return ref.Kind == "SealedSecret" && strings.HasPrefix(ref.APIVersion, "bitnami.com/")
+	})
+}
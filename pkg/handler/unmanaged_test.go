@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	ssv1alpha1 "github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/metadata"
+	metadatafake "k8s.io/client-go/metadata/fake"
+	"k8s.io/client-go/rest"
+	ktesting "k8s.io/client-go/testing"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UnmanagedSecrets", func() {
+	var (
+		recorder *httptest.ResponseRecorder
+		c        *gin.Context
+		cfg      *config.Config
+		core     *fake.Clientset
+		meta     *metadatafake.FakeMetadataClient
+		handler  *SecretsHandler
+	)
+
+	secret := func(ns, name string, owners ...metav1.OwnerReference) *metav1.PartialObjectMetadata {
+		return &metav1.PartialObjectMetadata{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name, OwnerReferences: owners},
+		}
+	}
+
+	BeforeEach(func() {
+		gin.SetMode(gin.ReleaseMode)
+		recorder = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(recorder)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/secrets/unmanaged", http.NoBody)
+		cfg = &config.Config{UnmanagedSecrets: config.UnmanagedSecrets{
+			SkipTypes: []string{string(corev1.SecretTypeServiceAccountToken), "helm.sh/release.v1"},
+		}}
+		core = fake.NewClientset(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		)
+		scheme := metadatafake.NewTestScheme()
+		Ω(metav1.AddMetaToScheme(scheme)).Should(Succeed())
+		meta = metadatafake.NewSimpleMetadataClient(scheme,
+			secret("app", "sealed", metav1.OwnerReference{
+				APIVersion: ssv1alpha1.SchemeGroupVersion.String(), Kind: "SealedSecret", Name: "sealed",
+			}),
+			secret("app", "manual"),
+			secret("app", "tls"),
+			secret("kube-system", "bootstrap"),
+		)
+	})
+	JustBeforeEach(func() {
+		handler = NewHandler(core.CoreV1(), nil, cfg)
+		handler.UseMetadataClients(func(*rest.ImpersonationConfig) (metadata.Interface, error) {
+			return meta, nil
+		})
+	})
+
+	It("should list the secrets without sealed secret owner", func() {
+		handler.UnmanagedSecrets(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).Should(Equal(`{"secrets":[` +
+			`{"namespace":"app","name":"manual"},` +
+			`{"namespace":"app","name":"tls"},` +
+			`{"namespace":"kube-system","name":"bootstrap"}]}`))
+	})
+
+	It("should skip the configured types by field selector", func() {
+		handler.UnmanagedSecrets(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(meta.Actions()).ShouldNot(BeEmpty())
+		for _, action := range meta.Actions() {
+			list, ok := action.(ktesting.ListAction)
+			Ω(ok).Should(BeTrue())
+			Ω(list.GetResource()).Should(Equal(corev1.SchemeGroupVersion.WithResource("secrets")))
+			Ω(list.GetListRestrictions().Fields.String()).
+				Should(Equal("type!=helm.sh/release.v1,type!=kubernetes.io/service-account-token"))
+		}
+	})
+
+	It("should only list the secrets of the allowed namespaces", func() {
+		cfg.ExcludeNamespaces = []string{"kube-system"}
+		handler.UnmanagedSecrets(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.Body.String()).ShouldNot(ContainSubstring("kube-system"))
+		Ω(recorder.Body.String()).Should(ContainSubstring(`"name":"manual"`))
+	})
+
+	It("should be forbidden without metadata client", func() {
+		NewHandler(core.CoreV1(), nil, cfg).UnmanagedSecrets(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusForbidden))
+	})
+
+	It("should be forbidden if loading secrets is disabled", func() {
+		cfg.DisableLoadSecrets = true
+		NewHandler(core.CoreV1(), nil, cfg).UnmanagedSecrets(c)
+
+		Ω(recorder.Code).Should(Equal(http.StatusForbidden))
+	})
+})
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/bakito/sealed-secrets-web/pkg/audit"
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+	"github.com/bakito/sealed-secrets-web/pkg/seal"
+)
+
+type unseal struct {
+	SealedSecret string `json:"sealedSecret"`
+	PrivateKey   string `json:"privateKey,omitempty"`
+}
+
+// Unseal decrypts a SealedSecret with the configured or uploaded private keys (break-glass mode).
+func (h *Handler) Unseal(c *gin.Context) {
+	outputContentType, outputFormat, done := NegotiateFormat(c)
+	if done {
+		return
+	}
+
+	ev, logAudit := startAudit(h.auditor, h.cfg, c, audit.OperationUnseal)
+	defer logAudit()
+
+	fail := func(code int, err error) {
+		contextNegotiate(c, code, gin.Negotiate{
+			Offered: []string{outputContentType},
+			Data:    gin.H{"error": err.Error()},
+		})
+	}
+
+	if status, err := h.unsealAllowed(c); err != nil {
+		fail(status, err)
+		return
+	}
+
+	data := &unseal{}
+	if err := c.ShouldBindJSON(data); err != nil {
+		fail(http.StatusUnprocessableEntity, err)
+		return
+	}
+	sealedSecret, err := readSealedSecret(scheme.Codecs.UniversalDecoder(), strings.NewReader(data.SealedSecret))
+	if err != nil {
+		fail(http.StatusUnprocessableEntity, err)
+		return
+	}
+	ev.Namespace = sealedSecret.Namespace
+	ev.Name = sealedSecret.Name
+	scope := sealedSecret.Scope()
+	ev.Scope = scope.String()
+	for k := range sealedSecret.Spec.EncryptedData {
+		ev.Keys = append(ev.Keys, k)
+	}
+	slices.Sort(ev.Keys)
+
+	keys, status, err := h.unsealKeys(data.PrivateKey)
+	if err != nil {
+		fail(status, err)
+		return
+	}
+
+	secret, err := seal.Unseal(sealedSecret, keys)
+	if err != nil {
+		fail(http.StatusUnprocessableEntity, fmt.Errorf("could not unseal: %w", err))
+		return
+	}
+	log.Printf("Unsealed sealed secret %s/%s in break-glass mode\n",
+		Sanitize(sealedSecret.Namespace), Sanitize(sealedSecret.Name))
+
+	secret, err = filterObject(secret, runtimeFields)
+	if err == nil {
+		var out []byte
+		if out, err = encodeSecret(secret, outputFormat); err == nil {
+			c.Header("Cache-Control", "no-store")
+			c.Data(http.StatusOK, outputContentType, out)
+			return
+		}
+	}
+	log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
+	fail(http.StatusInternalServerError, err)
+}
+
+// unsealAllowed checks whether the user of the request is allowed to unseal.
+func (h *Handler) unsealAllowed(c *gin.Context) (int, error) {
+	return unsealAllowed(c, h.cfg)
+}
+
+// unsealAllowed checks that unsealing is enabled and the user is known, from the login or the trusted headers.
+// If groups are configured, the user must be member of one of them.
+func unsealAllowed(c *gin.Context, cfg *config.Config) (int, error) {
+	if cfg == nil || !cfg.Unseal.Enabled {
+		return http.StatusForbidden, errors.New("unsealing is disabled")
+	}
+
+	user, ok := trustedIdentity(c, cfg)
+	if !ok {
+		return http.StatusUnauthorized, errors.New("no user identity to authorize unsealing")
+	}
+	if len(cfg.Unseal.Groups) == 0 {
+		return http.StatusOK, nil
+	}
+	if !slices.ContainsFunc(user.Groups, func(g string) bool { return slices.Contains(cfg.Unseal.Groups, g) }) {
+		return http.StatusForbidden, fmt.Errorf("user '%s' is not allowed to unseal", user.UserName)
+	}
+	return http.StatusOK, nil
+}
+
+// unsealKeys returns the configured private keys and the uploaded one.
+func (h *Handler) unsealKeys(uploaded string) (seal.PrivateKeys, int, error) {
+	keys, err := privateKeys(h.cfg)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if uploaded != "" {
+		if !h.cfg.Unseal.AllowUpload {
+			return nil, http.StatusForbidden, errors.New("uploading private keys is disabled")
+		}
+		if err := keys.Add([]byte(uploaded)); err != nil {
+			return nil, http.StatusUnprocessableEntity, fmt.Errorf("invalid private key: %w", err)
+		}
+	}
+	return keys, http.StatusOK, nil
+}
+
+// privateKeys reads the private keys of the break-glass mode. No keys are returned if it is not enabled.
+func privateKeys(cfg *config.Config) (seal.PrivateKeys, error) {
+	keys := seal.PrivateKeys{}
+	if cfg == nil || !cfg.Unseal.Enabled || cfg.Unseal.KeysDir == "" {
+		return keys, nil
+	}
+	keys, err := seal.ReadPrivateKeys(cfg.Unseal.KeysDir)
+	if err != nil {
+		log.Printf("Error reading the private keys: %v\n", err)
+		return nil, errors.New("could not read the private keys")
+	}
+	return keys, nil
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/bitnami/sealed-secrets/pkg/kubeseal"
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bakito/sealed-secrets-web/pkg/auth"
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Handler ", func() {
+	Context("Unseal", func() {
+		var (
+			recorder     *httptest.ResponseRecorder
+			c            *gin.Context
+			h            *Handler
+			cfg          *config.Config
+			sealedSecret string
+			privateKey   []byte
+		)
+		BeforeEach(func() {
+			gin.SetMode(gin.ReleaseMode)
+			recorder = httptest.NewRecorder()
+			c, _ = gin.CreateTestContext(recorder)
+
+			f, err := os.Open("../../testdata/cert.pem")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer func() { _ = f.Close() }()
+			pubKey, err := kubeseal.ParseKey(f)
+			Ω(err).ShouldNot(HaveOccurred())
+			ss, err := v1alpha1.NewSealedSecret(scheme.Codecs, pubKey, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "mynamespace"},
+				Data:       map[string][]byte{"password": []byte("s3cret")},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			b, err := yaml.Marshal(ss)
+			Ω(err).ShouldNot(HaveOccurred())
+			sealedSecret = string(b)
+
+			privateKey, err = os.ReadFile("../../testdata/key.pem")
+			Ω(err).ShouldNot(HaveOccurred())
+			keyDir := GinkgoT().TempDir()
+			Ω(os.WriteFile(filepath.Join(keyDir, "key.pem"), privateKey, 0o600)).Should(Succeed())
+
+			cfg = &config.Config{Unseal: config.Unseal{Enabled: true, KeysDir: keyDir}}
+			h = &Handler{cfg: cfg}
+			auth.SetUser(c, &auth.User{Name: "jane@example.com"})
+		})
+
+		request := func(data unseal) {
+			b, err := json.Marshal(data)
+			Ω(err).ShouldNot(HaveOccurred())
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/unseal", bytes.NewReader(b))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request.Header.Set("Accept", "application/yaml")
+		}
+
+		It("should unseal with the configured keys", func() {
+			request(unseal{SealedSecret: sealedSecret})
+			h.Unseal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+			Ω(recorder.Header().Get("Cache-Control")).Should(Equal("no-store"))
+			Ω(recorder.Body.String()).Should(Equal(`apiVersion: v1
+data:
+  password: czNjcmV0
+kind: Secret
+metadata:
+  name: mysecret
+  namespace: mynamespace
+`))
+		})
+
+		It("should be disabled by default", func() {
+			cfg.Unseal.Enabled = false
+			request(unseal{SealedSecret: sealedSecret})
+			h.Unseal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusForbidden))
+			Ω(recorder.Body.String()).Should(Equal("error: unsealing is disabled\n"))
+		})
+
+		It("should require a user identity", func() {
+			c, _ = gin.CreateTestContext(recorder)
+			request(unseal{SealedSecret: sealedSecret})
+			h.Unseal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnauthorized))
+			Ω(recorder.Body.String()).Should(Equal("error: no user identity to authorize unsealing\n"))
+		})
+
+		It("should unseal with an uploaded key if allowed", func() {
+			cfg.Unseal.KeysDir = ""
+			cfg.Unseal.AllowUpload = true
+			request(unseal{SealedSecret: sealedSecret, PrivateKey: string(privateKey)})
+			h.Unseal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusOK))
+		})
+
+		It("should not accept an uploaded key if not allowed", func() {
+			request(unseal{SealedSecret: sealedSecret, PrivateKey: string(privateKey)})
+			h.Unseal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusForbidden))
+			Ω(recorder.Body.String()).Should(Equal("error: uploading private keys is disabled\n"))
+		})
+
+		It("should fail with an unknown key", func() {
+			cfg.Unseal.KeysDir = GinkgoT().TempDir()
+			request(unseal{SealedSecret: sealedSecret})
+			h.Unseal(c)
+
+			Ω(recorder.Code).Should(Equal(http.StatusUnprocessableEntity))
+			Ω(recorder.Body.String()).Should(ContainSubstring("could not unseal"))
+		})
+
+		Context("groups", func() {
+			BeforeEach(func() {
+				cfg.Unseal.Groups = []string{"recovery"}
+				cfg.Impersonation = config.Impersonation{
+					Enabled:      true,
+					UserHeader:   "X-Forwarded-User",
+					GroupsHeader: "X-Forwarded-Groups",
+				}
+				c, _ = gin.CreateTestContext(recorder)
+				request(unseal{SealedSecret: sealedSecret})
+				c.Request.Header.Set("X-Forwarded-User", "jane")
+			})
+			It("should allow the members of the groups", func() {
+				c.Request.Header.Set("X-Forwarded-Groups", "dev, recovery")
+				h.Unseal(c)
+				Ω(recorder.Code).Should(Equal(http.StatusOK))
+			})
+			It("should deny other users", func() {
+				c.Request.Header.Set("X-Forwarded-Groups", "dev")
+				h.Unseal(c)
+				Ω(recorder.Code).Should(Equal(http.StatusForbidden))
+				Ω(recorder.Body.String()).Should(Equal("error: user 'jane' is not allowed to unseal\n"))
+			})
+			It("should not trust the headers without impersonation", func() {
+				cfg.Impersonation.Enabled = false
+				c.Request.Header.Set("X-Forwarded-Groups", "recovery")
+				h.Unseal(c)
+				Ω(recorder.Code).Should(Equal(http.StatusUnauthorized))
+			})
+		})
+	})
+})
@@ -1,20 +1,25 @@
 package handler
 
 import (
-	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/bakito/sealed-secrets-web/pkg/metrics"
 )
 
+// Validate checks if the sealed secret can be decrypted by the controller of the target. Targets without access to
+// the controller service only check the structure, unless a verify URL is configured.
 func (h *Handler) Validate(c *gin.Context) {
-	if h.cfg.SealedSecrets.CertURL != "" {
-		configError := fmt.Errorf("validate can't be used with CertURL (%s)", h.cfg.SealedSecrets.CertURL)
-		c.Data(http.StatusConflict, "text/plain", []byte(configError.Error()))
+	defer h.observe(c, metrics.OperationValidate)
+
+	sealer, err := h.sealerFor(c)
+	if err != nil {
+		c.Data(http.StatusNotFound, "text/plain", []byte(err.Error()))
 		return
 	}
-	err := h.sealer.Validate(c, c.Request.Body)
+	err = sealer.Validate(c, c.Request.Body)
 
 	if err != nil {
 		log.Printf("Error in %s: %v\n", Sanitize(c.FullPath()), err)
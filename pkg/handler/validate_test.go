@@ -65,18 +65,17 @@ var _ = Describe("Handler ", func() {
 			Ω(recorder.Header().Get("Content-Type")).Should(Equal("text/plain"))
 		})
 
-		It("should return an error if certURL is used", func() {
+		It("should validate with the sealer if certURL is used", func() {
 			cfg.SealedSecrets.CertURL = "http://sealed-secrets/v1/cert.pem"
 			c.Request, _ = http.NewRequest(http.MethodPost, "/v1/validate", bytes.NewReader([]byte(stringDataAsYAML)))
 			c.Request.Header.Set("Content-Type", "application/yaml")
 
+			sealer.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(errors.New("invalid sealed secret"))
+
 			h.Validate(c)
 
-			Ω(recorder.Code).Should(Equal(http.StatusConflict))
-			Ω(
-				recorder.Body.String(),
-			).Should(Equal("validate can't be used with CertURL (http://sealed-secrets/v1/cert.pem)"))
-			Ω(recorder.Header().Get("Content-Type")).Should(Equal("text/plain"))
+			Ω(recorder.Code).Should(Equal(http.StatusBadRequest))
+			Ω(recorder.Body.String()).Should(Equal("invalid sealed secret"))
 		})
 	})
 })
@@ -0,0 +1,362 @@
+// Package importer builds Kubernetes Secrets from the key value pairs of .env, properties, json and yaml files.
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Format is the format of the imported file.
+type Format string
+
+const (
+	FormatEnv        Format = "env"
+	FormatProperties Format = "properties"
+	FormatJSON       Format = "json"
+	FormatYAML       Format = "yaml"
+)
+
+// Spec describes the secret the imported values are written to.
+type Spec struct {
+	Format    Format
+	Name      string
+	Namespace string
+	// Type of the secret, Opaque if empty.
+	Type corev1.SecretType
+}
+
+// InvalidInputError lists the problems of the imported file, e.g. lines that can not be parsed or duplicate keys.
+type InvalidInputError struct {
+	Format   Format
+	Problems []string
+}
+
+func (e *InvalidInputError) Error() string {
+	return fmt.Sprintf("invalid %s input: %s", e.Format, strings.Join(e.Problems, "; "))
+}
+
+// Secret parses the data in the format of the spec and returns the secret with the values as stringData.
+func Secret(spec *Spec, data []byte) (*corev1.Secret, error) {
+	if spec.Name == "" {
+		return nil, errors.New("the secret must have a name")
+	}
+
+	p := &parser{values: map[string]string{}, lines: map[string]int{}}
+	switch spec.Format {
+	case FormatEnv:
+		p.env(string(data))
+	case FormatProperties:
+		p.properties(string(data))
+	case FormatJSON:
+		p.json(data)
+	case FormatYAML:
+		p.yaml(data)
+	default:
+		return nil, fmt.Errorf("unsupported format '%s', must be one of %s, %s, %s, %s",
+			spec.Format, FormatEnv, FormatProperties, FormatJSON, FormatYAML)
+	}
+	if len(p.problems) > 0 {
+		return nil, &InvalidInputError{Format: spec.Format, Problems: p.problems}
+	}
+
+	secretType := spec.Type
+	if secretType == "" {
+		secretType = corev1.SecretTypeOpaque
+	}
+	return &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: spec.Name, Namespace: spec.Namespace},
+		Type:       secretType,
+		StringData: p.values,
+	}, nil
+}
+
+// parser collects the values and the problems of the input. Lines are 1-based, 0 if not known.
+type parser struct {
+	values   map[string]string
+	lines    map[string]int
+	problems []string
+}
+
+func (p *parser) problem(line int, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if line > 0 {
+		msg = fmt.Sprintf("line %d: %s", line, msg)
+	}
+	p.problems = append(p.problems, msg)
+}
+
+func (p *parser) add(line int, key, value string) {
+	if first, ok := p.lines[key]; ok {
+		if line > 0 {
+			p.problems = append(p.problems, fmt.Sprintf("duplicate key '%s' on lines %d and %d", key, first, line))
+		} else {
+			p.problems = append(p.problems, fmt.Sprintf("duplicate key '%s'", key))
+		}
+		return
+	}
+	p.lines[key] = line
+	p.values[key] = value
+}
+
+// env parses a .env file: KEY=value lines with an optional 'export' prefix. Values may be single quoted (literal) or
+// double quoted (with escapes), both can span multiple lines. Unquoted values end at a ' #' comment.
+func (p *parser) env(data string) {
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "export"); ok && rest != "" && unicode.IsSpace(rune(rest[0])) {
+			line = strings.TrimSpace(rest)
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			p.problem(lineNo, "expected KEY=value")
+			continue
+		}
+		value = strings.TrimLeftFunc(value, unicode.IsSpace)
+
+		if value == "" || (value[0] != '"' && value[0] != '\'') {
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = value[:idx]
+			}
+			p.add(lineNo, key, strings.TrimSpace(value))
+			continue
+		}
+
+		// a quoted value continues on the following lines until the closing quote
+		quote := value[0]
+		raw := value[1:]
+		end := closingQuote(raw, quote)
+		for end < 0 && i+1 < len(lines) {
+			i++
+			raw += "\n" + lines[i]
+			end = closingQuote(raw, quote)
+		}
+		if end < 0 {
+			p.problem(lineNo, "unterminated quoted value of '%s'", key)
+			continue
+		}
+		if trailing := strings.TrimSpace(raw[end+1:]); trailing != "" && !strings.HasPrefix(trailing, "#") {
+			p.problem(i+1, "unexpected characters after the quoted value of '%s'", key)
+			continue
+		}
+		value = raw[:end]
+		if quote == '"' {
+			value = unescapeEnv(value)
+		}
+		p.add(lineNo, key, value)
+	}
+}
+
+// closingQuote returns the index of the closing quote, double quotes can be escaped with a backslash.
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeEnv(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '"', '\\', '$':
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// properties parses a Java properties file: keys are separated by '=', ':' or whitespace, lines ending with a
+// backslash are continued, comments start with '#' or '!'.
+func (p *parser) properties(data string) {
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimLeftFunc(lines[i], unicode.IsSpace)
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		for continued(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeftFunc(lines[i], unicode.IsSpace)
+		}
+
+		key, value, err := splitProperty(line)
+		if err != nil {
+			p.problem(lineNo, "%v", err)
+			continue
+		}
+		p.add(lineNo, key, value)
+	}
+}
+
+// continued returns true if the line ends with an odd number of backslashes.
+func continued(line string) bool {
+	n := len(line) - len(strings.TrimRight(line, "\\"))
+	return n%2 == 1
+}
+
+func splitProperty(line string) (key, value string, err error) {
+	var b strings.Builder
+	i := 0
+	for ; i < len(line); i++ {
+		ch := line[i]
+		if ch == '\\' && i+1 < len(line) {
+			b.WriteString(line[i : i+2])
+			i++
+			continue
+		}
+		if ch == '=' || ch == ':' || ch == ' ' || ch == '\t' || ch == '\f' {
+			break
+		}
+		b.WriteByte(ch)
+	}
+	if key, err = unescapeProperty(b.String()); err != nil {
+		return "", "", err
+	}
+	if key == "" {
+		return "", "", errors.New("expected key=value")
+	}
+
+	rest := strings.TrimLeft(line[i:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	value, err = unescapeProperty(rest)
+	return key, value, err
+}
+
+func unescapeProperty(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("invalid unicode escape '\\%s'", s[i:])
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape '\\u%s'", s[i+1:i+5])
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// json parses a flat json object with string, number or boolean values.
+func (p *parser) json(data []byte) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		p.problem(0, "expected a json object")
+		return
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			p.problem(0, "%v", err)
+			return
+		}
+		key, _ := t.(string)
+		var value any
+		if err := dec.Decode(&value); err != nil {
+			p.problem(0, "%v", err)
+			return
+		}
+		switch v := value.(type) {
+		case string:
+			p.add(0, key, v)
+		case json.Number:
+			p.add(0, key, v.String())
+		case bool:
+			p.add(0, key, strconv.FormatBool(v))
+		default:
+			p.problem(0, "value of '%s' must be a string, number or boolean", key)
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		p.problem(0, "%v", err)
+		return
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		p.problem(0, "unexpected data after the json object")
+	}
+}
+
+// yaml parses a flat yaml mapping with scalar values.
+func (p *parser) yaml(data []byte) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		p.problem(0, "%v", err)
+		return
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		p.problem(0, "expected a yaml mapping")
+		return
+	}
+	mapping := doc.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		k, v := mapping.Content[i], mapping.Content[i+1]
+		if k.Kind != yaml.ScalarNode {
+			p.problem(k.Line, "keys must be scalars")
+			continue
+		}
+		if v.Kind != yaml.ScalarNode || v.Tag == "!!null" {
+			p.problem(v.Line, "value of '%s' must be a string, number or boolean", k.Value)
+			continue
+		}
+		p.add(k.Line, k.Value, v.Value)
+	}
+}
@@ -0,0 +1,160 @@
+package importer
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Importer", func() {
+	values := func(format Format, data string) map[string]string {
+		secret, err := Secret(&Spec{Format: format, Name: "mysecret"}, []byte(data))
+		Ω(err).ShouldNot(HaveOccurred())
+		return secret.StringData
+	}
+
+	It("should build the secret", func() {
+		secret, err := Secret(&Spec{
+			Format:    FormatEnv,
+			Name:      "mysecret",
+			Namespace: "mynamespace",
+			Type:      corev1.SecretTypeBasicAuth,
+		}, []byte("username=admin\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(secret.Kind).Should(Equal("Secret"))
+		Ω(secret.APIVersion).Should(Equal("v1"))
+		Ω(secret.Name).Should(Equal("mysecret"))
+		Ω(secret.Namespace).Should(Equal("mynamespace"))
+		Ω(secret.Type).Should(Equal(corev1.SecretTypeBasicAuth))
+		Ω(secret.StringData).Should(Equal(map[string]string{"username": "admin"}))
+	})
+
+	It("should use the opaque type by default", func() {
+		secret, err := Secret(&Spec{Format: FormatEnv, Name: "mysecret"}, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(secret.Type).Should(Equal(corev1.SecretTypeOpaque))
+	})
+
+	It("should require a name", func() {
+		_, err := Secret(&Spec{Format: FormatEnv}, nil)
+		Ω(err).Should(MatchError("the secret must have a name"))
+	})
+
+	It("should reject an unknown format", func() {
+		_, err := Secret(&Spec{Format: "xml", Name: "mysecret"}, nil)
+		Ω(err).Should(MatchError("unsupported format 'xml', must be one of env, properties, json, yaml"))
+	})
+
+	Context("env", func() {
+		It("should parse the values", func() {
+			Ω(values(FormatEnv, `# comment
+export USER=admin
+PLAIN = value with spaces # comment
+EMPTY=
+URL=http://host/#anchor
+SINGLE='$literal \n'
+DOUBLE="say \"hi\"\tnow"
+MULTI="line 1
+line 2"
+KEY='-----BEGIN KEY-----
+abc
+-----END KEY-----' # the key
+exporter=1
+`)).Should(Equal(map[string]string{
+				"USER":     "admin",
+				"PLAIN":    "value with spaces",
+				"EMPTY":    "",
+				"URL":      "http://host/#anchor",
+				"SINGLE":   `$literal \n`,
+				"DOUBLE":   "say \"hi\"\tnow",
+				"MULTI":    "line 1\nline 2",
+				"KEY":      "-----BEGIN KEY-----\nabc\n-----END KEY-----",
+				"exporter": "1",
+			}))
+		})
+
+		It("should report the invalid lines and duplicates", func() {
+			_, err := Secret(&Spec{Format: FormatEnv, Name: "mysecret"}, []byte(`A=1
+invalid
+A=2
+B="open
+`))
+			Ω(err).Should(MatchError("invalid env input: line 2: expected KEY=value; " +
+				"duplicate key 'A' on lines 1 and 3; line 4: unterminated quoted value of 'B'"))
+			var invalid *InvalidInputError
+			Ω(err).Should(BeAssignableToTypeOf(invalid))
+		})
+	})
+
+	Context("properties", func() {
+		It("should parse the values", func() {
+			Ω(values(FormatProperties, `# comment
+! comment
+user=admin
+password : s3cret
+url http://host:8080/
+long = first \
+       second
+path=C:\\temp
+key\ with\ spaces=value
+unicode=caf\u00e9
+empty
+`)).Should(Equal(map[string]string{
+				"user":            "admin",
+				"password":        "s3cret",
+				"url":             "http://host:8080/",
+				"long":            "first second",
+				"path":            `C:\temp`,
+				"key with spaces": "value",
+				"unicode":         "café",
+				"empty":           "",
+			}))
+		})
+
+		It("should report duplicates", func() {
+			_, err := Secret(&Spec{Format: FormatProperties, Name: "mysecret"}, []byte("a=1\n\na:2\n"))
+			Ω(err).Should(MatchError("invalid properties input: duplicate key 'a' on lines 1 and 3"))
+		})
+	})
+
+	Context("json", func() {
+		It("should parse the values", func() {
+			Ω(values(FormatJSON, `{"user": "admin", "port": 5432, "ratio": 0.5, "tls": true}`)).
+				Should(Equal(map[string]string{"user": "admin", "port": "5432", "ratio": "0.5", "tls": "true"}))
+		})
+
+		It("should report nested values and duplicates", func() {
+			_, err := Secret(&Spec{Format: FormatJSON, Name: "mysecret"},
+				[]byte(`{"a": "1", "b": {"c": 1}, "d": null, "a": "2"}`))
+			Ω(err).Should(MatchError("invalid json input: value of 'b' must be a string, number or boolean; " +
+				"value of 'd' must be a string, number or boolean; duplicate key 'a'"))
+		})
+
+		It("should require an object", func() {
+			_, err := Secret(&Spec{Format: FormatJSON, Name: "mysecret"}, []byte(`["a"]`))
+			Ω(err).Should(MatchError("invalid json input: expected a json object"))
+		})
+	})
+
+	Context("yaml", func() {
+		It("should parse the values", func() {
+			Ω(values(FormatYAML, `user: admin
+port: 5432
+cert: |
+  line 1
+  line 2
+`)).Should(Equal(map[string]string{"user": "admin", "port": "5432", "cert": "line 1\nline 2\n"}))
+		})
+
+		It("should report nested values and duplicates", func() {
+			_, err := Secret(&Spec{Format: FormatYAML, Name: "mysecret"}, []byte(`a: 1
+b:
+  c: 1
+a: 2
+`))
+			Ω(err).Should(MatchError("invalid yaml input: line 3: value of 'b' must be a string, number or boolean; " +
+				"duplicate key 'a' on lines 1 and 4"))
+		})
+	})
+})
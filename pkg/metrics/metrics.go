@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "sealed_secrets_web"
+
+// Operations counted by ObserveOperation.
+const (
+	OperationSeal      = "seal"
+	OperationMerge     = "merge"
+	OperationRaw       = "raw"
+	OperationValidate  = "validate"
+	OperationApply     = "apply"
+	OperationReEncrypt = "reencrypt"
+)
+
+// UnknownTarget is the target label of the operations with a target that is not configured.
+const UnknownTarget = "unknown"
+
+// Result labels of the certificate fetches.
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of http requests by route, method and status.",
+	}, []string{"route", "method", "status"})
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the http requests by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	operations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "operations_total",
+		Help:      "Number of seal, merge, raw, validate and apply operations by target.",
+	}, []string{"operation", "target"})
+	operationErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "operation_errors_total",
+		Help:      "Number of seal, merge, raw, validate and apply operations failed with a server error by target.",
+	}, []string{"operation", "target"})
+
+	certificateFetches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "certificate_fetches_total",
+		Help:      "Number of sealing certificate fetches by target and result.",
+	}, []string{"target", "result"})
+	certificateFetchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "certificate_fetch_duration_seconds",
+		Help:      "Latency of the sealing certificate fetches by target.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"target"})
+	certificateLastSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "certificate_last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful sealing certificate fetch by target.",
+	}, []string{"target"})
+
+	kubernetesRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "kubernetes_request_duration_seconds",
+		Help:      "Latency of the Kubernetes API calls by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	sealedSecrets = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sealed_secrets",
+		Help:      "Number of SealedSecrets by synced state (true, false or unknown).",
+	}, []string{"synced"})
+)
+
+// Handler serves the metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records the count and latency of the http requests.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequests.WithLabelValues(route, c.Request.Method, strconv.Itoa(c.Writer.Status())).Inc()
+		httpRequestDuration.WithLabelValues(route, c.Request.Method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveOperation counts the operation. Operations answered with a server error are counted as errors.
+func ObserveOperation(operation, target string, status int) {
+	operations.WithLabelValues(operation, target).Inc()
+	if status >= http.StatusInternalServerError {
+		operationErrors.WithLabelValues(operation, target).Inc()
+	}
+}
+
+// ObserveCertificateFetch records the result and latency of a sealing certificate fetch.
+func ObserveCertificateFetch(target string, duration time.Duration, err error) {
+	certificateFetchDuration.WithLabelValues(target).Observe(duration.Seconds())
+	if err != nil {
+		certificateFetches.WithLabelValues(target, resultFailure).Inc()
+		return
+	}
+	certificateFetches.WithLabelValues(target, resultSuccess).Inc()
+	certificateLastSuccess.WithLabelValues(target).SetToCurrentTime()
+}
+
+// ObserveKubernetesRequest records the latency of a Kubernetes API call.
+func ObserveKubernetesRequest(operation string, duration time.Duration) {
+	kubernetesRequestDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+// SetSealedSecrets sets the number of SealedSecrets by synced state.
+func SetSealedSecrets(synced, notSynced, unknown int) {
+	sealedSecrets.WithLabelValues("true").Set(float64(synced))
+	sealedSecrets.WithLabelValues("false").Set(float64(notSynced))
+	sealedSecrets.WithLabelValues("unknown").Set(float64(unknown))
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metrics", func() {
+	It("should count the requests by route", func() {
+		gin.SetMode(gin.ReleaseMode)
+		r := gin.New()
+		r.Use(Middleware())
+		r.GET("/api/items/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+		before := testutil.ToFloat64(httpRequests.WithLabelValues("/api/items/:id", http.MethodGet, "204"))
+		req, _ := http.NewRequest(http.MethodGet, "/api/items/1", http.NoBody)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		req, _ = http.NewRequest(http.MethodGet, "/unknown", http.NoBody)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		Ω(testutil.ToFloat64(httpRequests.WithLabelValues("/api/items/:id", http.MethodGet, "204"))).
+			Should(Equal(before + 1))
+		Ω(testutil.ToFloat64(httpRequests.WithLabelValues("unmatched", http.MethodGet, "404"))).
+			Should(BeNumerically(">=", 1))
+	})
+
+	It("should count operation errors", func() {
+		ObserveOperation(OperationSeal, "test-errors", http.StatusOK)
+		ObserveOperation(OperationSeal, "test-errors", http.StatusUnprocessableEntity)
+		ObserveOperation(OperationSeal, "test-errors", http.StatusInternalServerError)
+
+		Ω(testutil.ToFloat64(operations.WithLabelValues(OperationSeal, "test-errors"))).Should(Equal(3.0))
+		Ω(testutil.ToFloat64(operationErrors.WithLabelValues(OperationSeal, "test-errors"))).Should(Equal(1.0))
+	})
+
+	It("should record the certificate fetches", func() {
+		ObserveCertificateFetch("test-cert", time.Millisecond, nil)
+		ObserveCertificateFetch("test-cert", time.Millisecond, errors.New("unreachable"))
+
+		Ω(testutil.ToFloat64(certificateFetches.WithLabelValues("test-cert", resultSuccess))).Should(Equal(1.0))
+		Ω(testutil.ToFloat64(certificateFetches.WithLabelValues("test-cert", resultFailure))).Should(Equal(1.0))
+		Ω(testutil.ToFloat64(certificateLastSuccess.WithLabelValues("test-cert"))).Should(BeNumerically(">", 0))
+	})
+
+	It("should set the sealed secrets by synced state", func() {
+		SetSealedSecrets(3, 2, 1)
+
+		Ω(testutil.ToFloat64(sealedSecrets.WithLabelValues("true"))).Should(Equal(3.0))
+		Ω(testutil.ToFloat64(sealedSecrets.WithLabelValues("false"))).Should(Equal(2.0))
+		Ω(testutil.ToFloat64(sealedSecrets.WithLabelValues("unknown"))).Should(Equal(1.0))
+	})
+})
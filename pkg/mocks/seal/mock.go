@@ -15,7 +15,9 @@ import (
 	reflect "reflect"
 
 	seal "github.com/bakito/sealed-secrets-web/pkg/seal"
+	v1alpha1 "github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
 	gomock "go.uber.org/mock/gomock"
+	v1 "k8s.io/api/core/v1"
 )
 
 // MockSealer is a mock of Sealer interface.
@@ -57,6 +59,50 @@ func (mr *MockSealerMockRecorder) Certificate(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Certificate", reflect.TypeOf((*MockSealer)(nil).Certificate), ctx)
 }
 
+// Fingerprint mocks base method.
+func (m *MockSealer) Fingerprint() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fingerprint")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Fingerprint indicates an expected call of Fingerprint.
+func (mr *MockSealerMockRecorder) Fingerprint() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fingerprint", reflect.TypeOf((*MockSealer)(nil).Fingerprint))
+}
+
+// KnownCertificates mocks base method.
+func (m *MockSealer) KnownCertificates(ctx context.Context) ([]seal.KnownCertificate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KnownCertificates", ctx)
+	ret0, _ := ret[0].([]seal.KnownCertificate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KnownCertificates indicates an expected call of KnownCertificates.
+func (mr *MockSealerMockRecorder) KnownCertificates(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KnownCertificates", reflect.TypeOf((*MockSealer)(nil).KnownCertificates), ctx)
+}
+
+// Merge mocks base method.
+func (m *MockSealer) Merge(outputFormat string, sealedSecret *v1alpha1.SealedSecret, secret *v1.Secret) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Merge", outputFormat, sealedSecret, secret)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Merge indicates an expected call of Merge.
+func (mr *MockSealerMockRecorder) Merge(outputFormat, sealedSecret, secret any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Merge", reflect.TypeOf((*MockSealer)(nil).Merge), outputFormat, sealedSecret, secret)
+}
+
 // Raw mocks base method.
 func (m *MockSealer) Raw(data seal.Raw) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -72,19 +118,34 @@ func (mr *MockSealerMockRecorder) Raw(data any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Raw", reflect.TypeOf((*MockSealer)(nil).Raw), data)
 }
 
+// ReEncrypt mocks base method.
+func (m *MockSealer) ReEncrypt(ctx context.Context, outputFormat string, sealedSecret *v1alpha1.SealedSecret, keys seal.PrivateKeys) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReEncrypt", ctx, outputFormat, sealedSecret, keys)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReEncrypt indicates an expected call of ReEncrypt.
+func (mr *MockSealerMockRecorder) ReEncrypt(ctx, outputFormat, sealedSecret, keys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReEncrypt", reflect.TypeOf((*MockSealer)(nil).ReEncrypt), ctx, outputFormat, sealedSecret, keys)
+}
+
 // Seal mocks base method.
-func (m *MockSealer) Seal(outputFormat string, secret io.Reader) ([]byte, error) {
+func (m *MockSealer) Seal(outputFormat string, scope v1alpha1.SealingScope, secret io.Reader) ([]byte, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Seal", outputFormat, secret)
+	ret := m.ctrl.Call(m, "Seal", outputFormat, scope, secret)
 	ret0, _ := ret[0].([]byte)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Seal indicates an expected call of Seal.
-func (mr *MockSealerMockRecorder) Seal(outputFormat, secret any) *gomock.Call {
+func (mr *MockSealerMockRecorder) Seal(outputFormat, scope, secret any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Seal", reflect.TypeOf((*MockSealer)(nil).Seal), outputFormat, secret)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Seal", reflect.TypeOf((*MockSealer)(nil).Seal), outputFormat, scope, secret)
 }
 
 // Validate mocks base method.
@@ -0,0 +1,280 @@
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Action is the outcome of a violated rule.
+type Action string
+
+const (
+	// ActionDeny rejects the sealing.
+	ActionDeny Action = "deny"
+	// ActionWarn seals the secret and reports the violation.
+	ActionWarn Action = "warn"
+)
+
+// Policy is a set of rules evaluated before a secret is sealed. A nil Policy allows everything.
+type Policy struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule is a single organisation-specific sealing rule. All conditions of a rule are checked for the secrets it matches.
+type Rule struct {
+	Name   string `yaml:"name"`
+	Action Action `yaml:"action,omitempty"` // default deny
+	Match  Match  `yaml:"match,omitempty"`
+	// RequiredLabels are the labels the secret (and therefore spec.template.metadata) must have.
+	RequiredLabels []string `yaml:"requiredLabels,omitempty"`
+	// AllowedScopes are the sealing scopes that may be used.
+	AllowedScopes []string `yaml:"allowedScopes,omitempty"`
+	// ForbiddenKeys are glob patterns of key names that must not be used.
+	ForbiddenKeys []string `yaml:"forbiddenKeys,omitempty"`
+	// MaxKeys is the maximum number of keys of the secret; 0 means unlimited.
+	MaxKeys int `yaml:"maxKeys,omitempty"`
+}
+
+// Match selects the secrets a rule applies to. An empty Match selects all secrets.
+type Match struct {
+	// Namespaces are glob patterns of the namespaces.
+	Namespaces []string `yaml:"namespaces,omitempty"`
+	// Types are the secret types; a secret without type is Opaque.
+	Types []string `yaml:"types,omitempty"`
+}
+
+// Input is the secret to evaluate.
+type Input struct {
+	Namespace string
+	Name      string
+	Type      corev1.SecretType
+	Labels    map[string]string
+	Keys      []string
+	Scope     v1alpha1.SealingScope
+	// Raw is a single raw value, that has no type, labels or keys. Only the namespace and scope rules apply.
+	Raw bool
+}
+
+// Violation is a rule that is violated by the secret.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Action  Action `json:"action"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+}
+
+// Result is the outcome of the evaluation.
+type Result struct {
+	Allowed    bool        `json:"allowed"`
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// Warnings returns the violations of the warn rules.
+func (r Result) Warnings() []Violation {
+	return r.filter(ActionWarn)
+}
+
+// Denials returns the violations of the deny rules.
+func (r Result) Denials() []Violation {
+	return r.filter(ActionDeny)
+}
+
+func (r Result) filter(action Action) []Violation {
+	var violations []Violation
+	for _, v := range r.Violations {
+		if v.Action == action {
+			violations = append(violations, v)
+		}
+	}
+	return violations
+}
+
+// Load reads the policy file. It returns nil if no file is configured.
+func Load(file string) (*Policy, error) {
+	if file == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// Parse parses and validates the yaml policy.
+func Parse(b []byte) (*Policy, error) {
+	p := &Policy{}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(p); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	return p, nil
+}
+
+func (p *Policy) validate() error {
+	names := make(map[string]bool)
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if r.Name == "" {
+			return fmt.Errorf("rule %d has no name", i)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("rule '%s' is defined more than once", r.Name)
+		}
+		names[r.Name] = true
+
+		switch r.Action {
+		case "":
+			r.Action = ActionDeny
+		case ActionDeny, ActionWarn:
+		default:
+			return fmt.Errorf("rule '%s': invalid action '%s', must be one of: deny, warn", r.Name, r.Action)
+		}
+		for _, s := range r.AllowedScopes {
+			var scope v1alpha1.SealingScope
+			if err := scope.Set(s); err != nil || s == "" {
+				return fmt.Errorf("rule '%s': invalid scope '%s', must be one of: strict, namespace-wide, cluster-wide",
+					r.Name, s)
+			}
+		}
+		for _, pattern := range slices.Concat(r.Match.Namespaces, r.ForbiddenKeys) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("rule '%s': invalid pattern '%s': %w", r.Name, pattern, err)
+			}
+		}
+		if r.MaxKeys < 0 {
+			return fmt.Errorf("rule '%s': maxKeys must not be negative", r.Name)
+		}
+	}
+	return nil
+}
+
+// FromSecret creates the input of a secret that is sealed with the given scope.
+func FromSecret(secret *corev1.Secret, scope v1alpha1.SealingScope) Input {
+	in := Input{
+		Namespace: secret.Namespace,
+		Name:      secret.Name,
+		Type:      secret.Type,
+		Labels:    secret.Labels,
+		Scope:     scope,
+	}
+	for k := range secret.Data {
+		in.Keys = append(in.Keys, k)
+	}
+	for k := range secret.StringData {
+		if _, ok := secret.Data[k]; !ok {
+			in.Keys = append(in.Keys, k)
+		}
+	}
+	slices.Sort(in.Keys)
+	return in
+}
+
+// Evaluate checks the input against all rules.
+func (p *Policy) Evaluate(in Input) Result {
+	res := Result{Allowed: true}
+	if p == nil {
+		return res
+	}
+	for _, r := range p.Rules {
+		if !r.matches(in) {
+			continue
+		}
+		for _, msg := range r.check(in) {
+			res.Violations = append(res.Violations, Violation{Rule: r.Name, Action: r.Action, Message: msg})
+			if r.Action == ActionDeny {
+				res.Allowed = false
+			}
+		}
+	}
+	return res
+}
+
+func (r Rule) matches(in Input) bool {
+	if len(r.Match.Namespaces) > 0 && !matchAny(r.Match.Namespaces, in.Namespace) {
+		return false
+	}
+	if len(r.Match.Types) > 0 {
+		if in.Raw {
+			return false
+		}
+		t := in.Type
+		if t == "" {
+			t = corev1.SecretTypeOpaque
+		}
+		if !slices.Contains(r.Match.Types, string(t)) {
+			return false
+		}
+	}
+	return true
+}
+
+// check returns the messages of the violated conditions.
+func (r Rule) check(in Input) []string {
+	var msgs []string
+	if len(r.AllowedScopes) > 0 && !slices.Contains(r.AllowedScopes, in.Scope.String()) {
+		msgs = append(msgs, fmt.Sprintf("scope '%s' is not allowed, allowed are: %s",
+			in.Scope.String(), strings.Join(r.AllowedScopes, ", ")))
+	}
+	if in.Raw {
+		return msgs
+	}
+
+	for _, l := range r.RequiredLabels {
+		if _, ok := in.Labels[l]; !ok {
+			msgs = append(msgs, fmt.Sprintf("label '%s' is required", l))
+		}
+	}
+	for _, k := range in.Keys {
+		if matchAny(r.ForbiddenKeys, k) {
+			msgs = append(msgs, fmt.Sprintf("key '%s' is not allowed", k))
+		}
+	}
+	if r.MaxKeys > 0 && len(in.Keys) > r.MaxKeys {
+		msgs = append(msgs, fmt.Sprintf("%d keys exceed the maximum of %d", len(in.Keys), r.MaxKeys))
+	}
+	return msgs
+}
+
+func matchAny(patterns []string, value string) bool {
+	return slices.ContainsFunc(patterns, func(p string) bool {
+		ok, _ := path.Match(p, value)
+		return ok
+	})
+}
+
+// DeniedError is returned if the secret is denied by the policy.
+type DeniedError struct {
+	Violations []Violation
+}
+
+func (e *DeniedError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.String())
+	}
+	return "denied by policy: " + strings.Join(msgs, "; ")
+}
+
+// Err returns a DeniedError if the result is not allowed.
+func (r Result) Err() error {
+	if r.Allowed {
+		return nil
+	}
+	return &DeniedError{Violations: r.Denials()}
+}
@@ -0,0 +1,121 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const testPolicy = `
+rules:
+  - name: team-label
+    requiredLabels: [team]
+  - name: strict-in-prod
+    match:
+      namespaces: ["prod-*"]
+    allowedScopes: [strict]
+  - name: no-passwords
+    action: warn
+    match:
+      types: [Opaque]
+    forbiddenKeys: ["password", "*passwd*"]
+  - name: max-keys
+    maxKeys: 2
+`
+
+var _ = Describe("Policy", func() {
+	var p *Policy
+	BeforeEach(func() {
+		var err error
+		p, err = Parse([]byte(testPolicy))
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	secret := func(namespace string, labels map[string]string, keys ...string) *corev1.Secret {
+		s := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "s", Labels: labels},
+			StringData: map[string]string{},
+		}
+		for _, k := range keys {
+			s.StringData[k] = "v"
+		}
+		return s
+	}
+
+	It("should allow a compliant secret", func() {
+		res := p.Evaluate(FromSecret(secret("prod-a", map[string]string{"team": "a"}, "user"), v1alpha1.StrictScope))
+		Ω(res).Should(Equal(Result{Allowed: true}))
+		Ω(res.Err()).ShouldNot(HaveOccurred())
+	})
+
+	It("should deny the violations of deny rules", func() {
+		res := p.Evaluate(FromSecret(secret("prod-a", nil, "a", "b", "c"), v1alpha1.ClusterWideScope))
+		Ω(res.Allowed).Should(BeFalse())
+		Ω(res.Denials()).Should(Equal([]Violation{
+			{Rule: "team-label", Action: ActionDeny, Message: "label 'team' is required"},
+			{Rule: "strict-in-prod", Action: ActionDeny, Message: "scope 'cluster-wide' is not allowed, allowed are: strict"},
+			{Rule: "max-keys", Action: ActionDeny, Message: "3 keys exceed the maximum of 2"},
+		}))
+		Ω(res.Err()).Should(MatchError(ContainSubstring("denied by policy: team-label: label 'team' is required")))
+	})
+
+	It("should only warn for warn rules", func() {
+		res := p.Evaluate(FromSecret(secret("dev", map[string]string{"team": "a"}, "password", "db_passwd"), v1alpha1.StrictScope))
+		Ω(res.Allowed).Should(BeTrue())
+		Ω(res.Warnings()).Should(Equal([]Violation{
+			{Rule: "no-passwords", Action: ActionWarn, Message: "key 'db_passwd' is not allowed"},
+			{Rule: "no-passwords", Action: ActionWarn, Message: "key 'password' is not allowed"},
+		}))
+	})
+
+	It("should not apply type rules to other types", func() {
+		s := secret("dev", map[string]string{"team": "a"}, "password")
+		s.Type = corev1.SecretTypeBasicAuth
+		Ω(p.Evaluate(FromSecret(s, v1alpha1.StrictScope)).Violations).Should(BeEmpty())
+	})
+
+	It("should only apply the namespace and scope rules to raw values", func() {
+		res := p.Evaluate(Input{Namespace: "prod-a", Name: "s", Scope: v1alpha1.NamespaceWideScope, Raw: true})
+		Ω(res.Violations).Should(Equal([]Violation{
+			{Rule: "strict-in-prod", Action: ActionDeny, Message: "scope 'namespace-wide' is not allowed, allowed are: strict"},
+		}))
+	})
+
+	It("should allow everything without policy", func() {
+		var nilPolicy *Policy
+		Ω(nilPolicy.Evaluate(Input{}).Allowed).Should(BeTrue())
+	})
+
+	DescribeTable("invalid policies",
+		func(policy, msg string) {
+			_, err := Parse([]byte(policy))
+			Ω(err).Should(MatchError(ContainSubstring(msg)))
+		},
+		Entry("no name", "rules: [{maxKeys: 1}]", "rule 0 has no name"),
+		Entry("duplicate", "rules: [{name: a}, {name: a}]", "rule 'a' is defined more than once"),
+		Entry("action", "rules: [{name: a, action: block}]", "invalid action 'block'"),
+		Entry("scope", "rules: [{name: a, allowedScopes: [wide]}]", "invalid scope 'wide'"),
+		Entry("pattern", "rules: [{name: a, forbiddenKeys: ['[']}]", "invalid pattern '['"),
+		Entry("unknown field", "rules: [{name: a, maxkeys: 1}]", "field maxkeys not found"),
+	)
+
+	Context("Load", func() {
+		It("should return nil without file", func() {
+			Ω(Load("")).Should(BeNil())
+		})
+		It("should load the file", func() {
+			file := filepath.Join(GinkgoT().TempDir(), "policy.yaml")
+			Ω(os.WriteFile(file, []byte(testPolicy), 0o600)).Should(Succeed())
+			p, err := Load(file)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(p.Rules).Should(HaveLen(4))
+			Ω(p.Rules[0].Action).Should(Equal(ActionDeny))
+		})
+	})
+})
@@ -0,0 +1,124 @@
+package seal
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"github.com/bitnami/sealed-secrets/pkg/crypto"
+	"github.com/bitnami/sealed-secrets/pkg/kubeseal"
+	certUtil "k8s.io/client-go/util/cert"
+)
+
+// certFileWatchInterval is the interval the certificate file is checked for changes.
+var certFileWatchInterval = 5 * time.Second
+
+// CertificateInfo describes the certificate currently used for sealing.
+type CertificateInfo struct {
+	Fingerprint string    `json:"fingerprint"`
+	NotBefore   time.Time `json:"notBefore"`
+	NotAfter    time.Time `json:"notAfter"`
+}
+
+// ParseCertificateInfo reads the fingerprint and validity of a PEM encoded sealing certificate.
+func ParseCertificateInfo(data []byte) (*CertificateInfo, error) {
+	certs, err := certUtil.ParseCertsPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate found")
+	}
+	pubKey, ok := certs[0].PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("expected RSA public key")
+	}
+	fp, err := crypto.PublicKeyFingerprint(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	return &CertificateInfo{
+		Fingerprint: fp,
+		NotBefore:   certs[0].NotBefore,
+		NotAfter:    certs[0].NotAfter,
+	}, nil
+}
+
+// publicKey returns the public key currently used for sealing.
+func (a *apiSealer) publicKey() *rsa.PublicKey {
+	a.keyLock.RLock()
+	defer a.keyLock.RUnlock()
+	return a.pubKey
+}
+
+// updateKey swaps in the key of the given certificate if it differs from the current one.
+func (a *apiSealer) updateKey(data []byte) error {
+	pubKey, err := kubeseal.ParseKey(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	fp, err := crypto.PublicKeyFingerprint(pubKey)
+	if err != nil {
+		return err
+	}
+
+	a.keyLock.Lock()
+	defer a.keyLock.Unlock()
+	if fp == a.fingerprint {
+		return nil
+	}
+	if a.fingerprint == "" {
+		log.Printf("Using sealing certificate with fingerprint %s\n", fp)
+	} else {
+		log.Printf("Sealing certificate changed from fingerprint %s to %s\n", a.fingerprint, fp)
+	}
+	a.pubKey = pubKey
+	a.fingerprint = fp
+	return nil
+}
+
+// refreshCertificate periodically fetches the certificate to pick up keys rotated by the controller.
+func (a *apiSealer) refreshCertificate(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := a.Certificate(ctx); err != nil {
+				log.Printf("Could not refresh the sealing certificate: %v\n", err)
+			}
+		}
+	}
+}
+
+// watchCertFile reloads the certificate file whenever its modification time or size changes.
+func (a *apiSealer) watchCertFile(ctx context.Context, interval time.Duration) {
+	last, _ := os.Stat(a.ss.CertFile)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fi, err := os.Stat(a.ss.CertFile)
+			if err != nil {
+				log.Printf("Could not read the sealing certificate file: %v\n", err)
+				continue
+			}
+			if last != nil && fi.ModTime().Equal(last.ModTime()) && fi.Size() == last.Size() {
+				continue
+			}
+			last = fi
+			if _, err := a.Certificate(ctx); err != nil {
+				log.Printf("Could not reload the sealing certificate file: %v\n", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package seal
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"maps"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/bitnami/sealed-secrets/pkg/crypto"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	certUtil "k8s.io/client-go/util/cert"
+)
+
+const (
+	// sealedSecretsKeyLabel marks the secrets of the controller keys.
+	sealedSecretsKeyLabel = "sealedsecrets.bitnami.com/sealed-secrets-key"
+	// gcmTagSize is the size of the authentication tag of the AES-GCM encrypted value.
+	gcmTagSize = 16
+
+	// Sources of the known certificates.
+	SourceCurrent    = "current"
+	SourceAdditional = "additional"
+	SourceController = "controller"
+)
+
+// KnownCertificate is a certificate a sealed secret may be sealed with.
+type KnownCertificate struct {
+	CertificateInfo
+	Sources []string `json:"sources"`
+	pubKey  *rsa.PublicKey
+}
+
+// ValueInspection is the result of the inspection of a single encrypted value.
+type ValueInspection struct {
+	Key string `json:"key"`
+	// Error describes a corrupted or truncated ciphertext.
+	Error string `json:"error,omitempty"`
+	// KeySize is the size in bits of the RSA key the value is encrypted with.
+	KeySize int `json:"keySize,omitempty"`
+	// Candidates are the fingerprints of the known certificates the value can belong to.
+	Candidates []string `json:"candidates"`
+	// SealedWith is the fingerprint of the private key that decrypts the value, if private keys are available.
+	SealedWith string `json:"sealedWith,omitempty"`
+}
+
+// KnownCertificates returns the current certificate, the additional certificates of the target and the certificates
+// of the controller keys. The controller keys are only read if enabled and the controller is reachable via its service.
+func (a *apiSealer) KnownCertificates(ctx context.Context) ([]KnownCertificate, error) {
+	var certs []KnownCertificate
+	current, err := a.Certificate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if certs, err = addCertificates(certs, current, SourceCurrent); err != nil {
+		return nil, err
+	}
+
+	for _, file := range a.ss.AdditionalCerts {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if certs, err = addCertificates(certs, data, SourceAdditional); err != nil {
+			return nil, fmt.Errorf("invalid certificate file %s: %w", file, err)
+		}
+	}
+
+	if a.ss.ReadControllerKeys && a.ss.CanValidate() {
+		keyCerts, err := a.controllerCertificates(ctx)
+		if err != nil {
+			log.Printf("Could not read the keys of the sealed secrets controller: %v\n", err)
+		}
+		for _, data := range keyCerts {
+			if certs, err = addCertificates(certs, data, SourceController); err != nil {
+				log.Printf("Skipping invalid certificate of the sealed secrets controller: %v\n", err)
+			}
+		}
+	}
+	return certs, nil
+}
+
+// controllerCertificates reads the certificates of the key secrets in the namespace of the controller.
+// The secrets contain the private keys too, so they are only read if enabled with ReadControllerKeys.
+func (a *apiSealer) controllerCertificates(ctx context.Context) ([][]byte, error) {
+	conf, err := a.clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.CoreV1().Secrets(a.ss.Namespace).List(ctx, metav1.ListOptions{LabelSelector: sealedSecretsKeyLabel})
+	if err != nil {
+		return nil, err
+	}
+	var certs [][]byte
+	for _, s := range list.Items {
+		if crt := s.Data[corev1.TLSCertKey]; len(crt) > 0 {
+			certs = append(certs, crt)
+		}
+	}
+	return certs, nil
+}
+
+// addCertificates adds the certificates of the PEM data, merging the sources of certificates already known.
+func addCertificates(certs []KnownCertificate, data []byte, source string) ([]KnownCertificate, error) {
+	parsed, err := certUtil.ParseCertsPEM(data)
+	if err != nil {
+		return certs, err
+	}
+	for _, c := range parsed {
+		pubKey, ok := c.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return certs, fmt.Errorf("expected RSA public key, got %T", c.PublicKey)
+		}
+		fp, err := crypto.PublicKeyFingerprint(pubKey)
+		if err != nil {
+			return certs, err
+		}
+		if i := slices.IndexFunc(certs, func(k KnownCertificate) bool { return k.Fingerprint == fp }); i >= 0 {
+			if !slices.Contains(certs[i].Sources, source) {
+				certs[i].Sources = append(certs[i].Sources, source)
+			}
+			continue
+		}
+		certs = append(certs, KnownCertificate{
+			CertificateInfo: CertificateInfo{Fingerprint: fp, NotBefore: c.NotBefore, NotAfter: c.NotAfter},
+			Sources:         []string{source},
+			pubKey:          pubKey,
+		})
+	}
+	return certs, nil
+}
+
+// Inspect checks the structure of the encrypted values of the sealed secret and determines the certificates they can
+// belong to. A value encrypted with an RSA key has the size of its modulus and is smaller than the modulus. With
+// private keys, the key of each value is identified by decrypting it.
+func Inspect(sealedSecret *v1alpha1.SealedSecret, certs []KnownCertificate, keys PrivateKeys) []ValueInspection {
+	var sealedWith map[string]string
+	if len(keys) > 0 {
+		sealedWith = SealedWith(sealedSecret, keys)
+	}
+
+	var result []ValueInspection
+	for _, key := range slices.Sorted(maps.Keys(sealedSecret.Spec.EncryptedData)) {
+		vi := ValueInspection{Key: key, Candidates: []string{}, SealedWith: sealedWith[key]}
+		rsaCiphertext, err := splitCiphertext(sealedSecret.Spec.EncryptedData[key])
+		if err != nil {
+			vi.Error = err.Error()
+			result = append(result, vi)
+			continue
+		}
+		vi.KeySize = len(rsaCiphertext) * 8
+
+		c := new(big.Int).SetBytes(rsaCiphertext)
+		for _, cert := range certs {
+			if cert.pubKey.Size() == len(rsaCiphertext) && c.Cmp(cert.pubKey.N) < 0 {
+				vi.Candidates = append(vi.Candidates, cert.Fingerprint)
+			}
+		}
+		result = append(result, vi)
+	}
+	return result
+}
+
+// splitCiphertext validates the structure of the hybrid encrypted value and returns its RSA encrypted session key:
+// 2 bytes length of the RSA ciphertext, the RSA ciphertext and the AES-GCM ciphertext with its tag.
+func splitCiphertext(value string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("not base64 encoded: %w", err)
+	}
+	if len(ciphertext) < 2 {
+		return nil, fmt.Errorf("truncated: %d bytes", len(ciphertext))
+	}
+	rsaLen := int(binary.BigEndian.Uint16(ciphertext))
+	if rsaLen == 0 || rsaLen%8 != 0 {
+		return nil, fmt.Errorf("corrupted: invalid session key length %d", rsaLen)
+	}
+	if len(ciphertext) < 2+rsaLen+gcmTagSize {
+		return nil, fmt.Errorf("truncated: %d bytes, expected at least %d", len(ciphertext), 2+rsaLen+gcmTagSize)
+	}
+	return ciphertext[2 : 2+rsaLen], nil
+}
@@ -0,0 +1,106 @@
+package seal
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/bitnami/sealed-secrets/pkg/crypto"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Inspect", func() {
+	var (
+		sealer     *apiSealer
+		currentFP  string
+		otherKey   *rsa.PrivateKey
+		otherFP    string
+		otherFile  string
+		testSealed *v1alpha1.SealedSecret
+	)
+	BeforeEach(func() {
+		var err error
+		otherKey, err = rsa.GenerateKey(rand.Reader, 3072)
+		Ω(err).ShouldNot(HaveOccurred())
+		otherFP, err = crypto.PublicKeyFingerprint(&otherKey.PublicKey)
+		Ω(err).ShouldNot(HaveOccurred())
+		cert, err := crypto.SignKey(rand.Reader, otherKey, time.Hour, "other")
+		Ω(err).ShouldNot(HaveOccurred())
+		otherFile = filepath.Join(GinkgoT().TempDir(), "other.pem")
+		Ω(os.WriteFile(otherFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o600)).
+			Should(Succeed())
+
+		sealer = &apiSealer{ss: config.SealedSecrets{CertFile: testCertFile, AdditionalCerts: []string{otherFile}}}
+		data, err := os.ReadFile(testCertFile)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(sealer.updateKey(data)).Should(Succeed())
+		currentFP = sealer.Fingerprint()
+
+		testSealed, err = v1alpha1.NewSealedSecret(scheme.Codecs, sealer.publicKey(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "mynamespace"},
+			Data:       map[string][]byte{"password": []byte("s3cret")},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should return the known certificates", func() {
+		certs, err := sealer.KnownCertificates(context.TODO())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(certs).Should(HaveLen(2))
+		Ω(certs[0].Fingerprint).Should(Equal(currentFP))
+		Ω(certs[0].Sources).Should(Equal([]string{SourceCurrent}))
+		Ω(certs[1].Fingerprint).Should(Equal(otherFP))
+		Ω(certs[1].Sources).Should(Equal([]string{SourceAdditional}))
+	})
+
+	It("should merge the sources of the same certificate", func() {
+		sealer.ss.AdditionalCerts = []string{testCertFile}
+		certs, err := sealer.KnownCertificates(context.TODO())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(certs).Should(HaveLen(1))
+		Ω(certs[0].Sources).Should(Equal([]string{SourceCurrent, SourceAdditional}))
+	})
+
+	It("should determine the certificates the values can belong to", func() {
+		certs, err := sealer.KnownCertificates(context.TODO())
+		Ω(err).ShouldNot(HaveOccurred())
+
+		valid := testSealed.Spec.EncryptedData["password"]
+		raw, err := base64.StdEncoding.DecodeString(valid)
+		Ω(err).ShouldNot(HaveOccurred())
+		testSealed.Spec.EncryptedData["broken"] = "not base64!"
+		testSealed.Spec.EncryptedData["truncated"] = base64.StdEncoding.EncodeToString(raw[:100])
+		testSealed.Spec.EncryptedData["length"] = base64.StdEncoding.EncodeToString(append([]byte{0, 3}, raw[2:]...))
+
+		Ω(Inspect(testSealed, certs, nil)).Should(Equal([]ValueInspection{
+			{Key: "broken", Candidates: []string{}, Error: "not base64 encoded: illegal base64 data at input byte 3"},
+			{Key: "length", Candidates: []string{}, Error: "corrupted: invalid session key length 3"},
+			{Key: "password", Candidates: []string{currentFP}, KeySize: 2048},
+			{Key: "truncated", Candidates: []string{}, Error: "truncated: 100 bytes, expected at least 274"},
+		}))
+	})
+
+	It("should identify the key with the private keys", func() {
+		keys := PrivateKeys{}
+		key, err := os.ReadFile(testKeyFile)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(keys.Add(key)).Should(Succeed())
+
+		Ω(Inspect(testSealed, nil, keys)).Should(Equal([]ValueInspection{
+			{Key: "password", Candidates: []string{}, KeySize: 2048, SealedWith: currentFP},
+		}))
+	})
+})
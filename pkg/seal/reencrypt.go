@@ -0,0 +1,113 @@
+package seal
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/bitnami/sealed-secrets/pkg/crypto"
+	"github.com/bitnami/sealed-secrets/pkg/kubeseal"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// Fingerprint returns the fingerprint of the public key currently used for sealing.
+func (a *apiSealer) Fingerprint() string {
+	a.keyLock.RLock()
+	defer a.keyLock.RUnlock()
+	return a.fingerprint
+}
+
+// ReEncrypt encrypts the values of the sealed secret with the current key, analogous to 'kubeseal --re-encrypt'.
+// With private keys, the values are decrypted and encrypted locally, otherwise the rotate endpoint of the
+// controller is used.
+func (a *apiSealer) ReEncrypt(
+	ctx context.Context,
+	outputFormat string,
+	sealedSecret *v1alpha1.SealedSecret,
+	keys PrivateKeys,
+) ([]byte, error) {
+	if len(keys) > 0 {
+		return a.reEncryptLocal(outputFormat, sealedSecret, keys)
+	}
+	if !a.ss.CanValidate() {
+		return nil, errors.New("re-encryption requires the sealed secrets controller service or the private keys")
+	}
+
+	in, err := encodeSealedSecret(sealedSecret, "json")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := kubeseal.ReEncryptSealedSecret(
+		ctx,
+		a.clientConfig,
+		a.ss.Namespace,
+		a.ss.Service,
+		outputFormat,
+		bytes.NewReader(in),
+		&buf,
+		scheme.Codecs,
+	); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (a *apiSealer) reEncryptLocal(
+	outputFormat string,
+	sealedSecret *v1alpha1.SealedSecret,
+	keys PrivateKeys,
+) ([]byte, error) {
+	scope := sealedSecret.Scope()
+	label := v1alpha1.EncryptionLabel(sealedSecret.Namespace, sealedSecret.Name, scope)
+	pubKey := a.publicKey()
+
+	reEncrypted := sealedSecret.DeepCopy()
+	for key, value := range sealedSecret.Spec.EncryptedData {
+		plain, err := decryptItem(keys, value, label)
+		if err != nil {
+			return nil, fmt.Errorf("could not decrypt '%s': %w", key, err)
+		}
+		var buf bytes.Buffer
+		if err := kubeseal.EncryptSecretItem(
+			&buf, sealedSecret.Name, sealedSecret.Namespace, plain,
+			scope, pubKey); err != nil {
+			return nil, err
+		}
+		reEncrypted.Spec.EncryptedData[key] = buf.String()
+	}
+	return encodeSealedSecret(reEncrypted, outputFormat)
+}
+
+// SealedWith returns the fingerprints of the private keys the values of the sealed secret were encrypted with.
+// The fingerprint of a value none of the keys can decrypt is empty.
+func SealedWith(sealedSecret *v1alpha1.SealedSecret, keys PrivateKeys) map[string]string {
+	scope := sealedSecret.Scope()
+	label := v1alpha1.EncryptionLabel(sealedSecret.Namespace, sealedSecret.Name, scope)
+	fingerprints := make(map[string]string, len(sealedSecret.Spec.EncryptedData))
+	for key, value := range sealedSecret.Spec.EncryptedData {
+		fingerprints[key] = ""
+		for _, fp := range slices.Sorted(maps.Keys(keys)) {
+			if _, err := decryptItem(PrivateKeys{fp: keys[fp]}, value, label); err == nil {
+				fingerprints[key] = fp
+				break
+			}
+		}
+	}
+	return fingerprints
+}
+
+func decryptItem(keys PrivateKeys, value string, label []byte) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.HybridDecrypt(rand.Reader, map[string]*rsa.PrivateKey(keys), ciphertext, label)
+}
@@ -0,0 +1,98 @@
+package seal
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"os"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/bitnami/sealed-secrets/pkg/crypto"
+	"github.com/bitnami/sealed-secrets/pkg/kubeseal"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReEncrypt", func() {
+	var (
+		oldKeys      PrivateKeys
+		oldFP        string
+		newKey       *rsa.PrivateKey
+		newFP        string
+		sealer       *apiSealer
+		sealedSecret *v1alpha1.SealedSecret
+	)
+	BeforeEach(func() {
+		oldKeys = PrivateKeys{}
+		key, err := os.ReadFile(testKeyFile)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(oldKeys.Add(key)).Should(Succeed())
+		for fp := range oldKeys {
+			oldFP = fp
+		}
+
+		newKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Ω(err).ShouldNot(HaveOccurred())
+		newFP, err = crypto.PublicKeyFingerprint(&newKey.PublicKey)
+		Ω(err).ShouldNot(HaveOccurred())
+		sealer = &apiSealer{pubKey: &newKey.PublicKey, fingerprint: newFP}
+
+		f, err := os.Open(testCertFile)
+		Ω(err).ShouldNot(HaveOccurred())
+		defer func() { _ = f.Close() }()
+		oldPubKey, err := kubeseal.ParseKey(f)
+		Ω(err).ShouldNot(HaveOccurred())
+		sealedSecret, err = v1alpha1.NewSealedSecret(scheme.Codecs, oldPubKey, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "mysecret",
+				Namespace:   "mynamespace",
+				Annotations: map[string]string{v1alpha1.SealedSecretNamespaceWideAnnotation: "true"},
+			},
+			Data: map[string][]byte{"password": []byte("s3cret"), "user": []byte("admin")},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should return the fingerprint of the current key", func() {
+		Ω(sealer.Fingerprint()).Should(Equal(newFP))
+	})
+
+	It("should identify the key the values are sealed with", func() {
+		Ω(SealedWith(sealedSecret, oldKeys)).Should(Equal(map[string]string{"password": oldFP, "user": oldFP}))
+		Ω(SealedWith(sealedSecret, PrivateKeys{newFP: newKey})).
+			Should(Equal(map[string]string{"password": "", "user": ""}))
+	})
+
+	It("should re-encrypt the values with the current key", func() {
+		out, err := sealer.ReEncrypt(context.TODO(), "yaml", sealedSecret, oldKeys)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		reEncrypted := &v1alpha1.SealedSecret{}
+		Ω(yaml.Unmarshal(out, reEncrypted)).Should(Succeed())
+		Ω(reEncrypted.Annotations).Should(Equal(sealedSecret.Annotations))
+		Ω(SealedWith(reEncrypted, PrivateKeys{oldFP: oldKeys[oldFP], newFP: newKey})).
+			Should(Equal(map[string]string{"password": newFP, "user": newFP}))
+
+		secret, err := Unseal(reEncrypted, PrivateKeys{newFP: newKey})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(secret.Data).Should(HaveKeyWithValue("password", []byte("s3cret")))
+	})
+
+	It("should fail if a value can not be decrypted", func() {
+		_, err := sealer.ReEncrypt(context.TODO(), "yaml", sealedSecret, PrivateKeys{newFP: newKey})
+		Ω(err).Should(MatchError(ContainSubstring("could not decrypt")))
+	})
+
+	It("should require the controller service without private keys", func() {
+		sealer.ss = config.SealedSecrets{CertURL: "https://example.com/cert.pem"}
+		_, err := sealer.ReEncrypt(context.TODO(), "yaml", sealedSecret, nil)
+		Ω(err).Should(MatchError(ContainSubstring("requires the sealed secrets controller service")))
+	})
+})
@@ -0,0 +1,71 @@
+package seal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+)
+
+// Target is a named sealed secrets controller to seal secrets for.
+type Target struct {
+	Name   string
+	Config config.SealedSecrets
+	Sealer Sealer
+}
+
+// Registry holds the sealers of all configured targets.
+type Registry struct {
+	targets []*Target
+	byName  map[string]*Target
+}
+
+// NewRegistry creates a registry for the given targets, the first target is the default one.
+func NewRegistry(targets ...*Target) *Registry {
+	r := &Registry{
+		byName: make(map[string]*Target),
+	}
+	for _, t := range targets {
+		r.targets = append(r.targets, t)
+		r.byName[t.Name] = t
+	}
+	return r
+}
+
+// NewAPIRegistry creates an api sealer for each of the given targets.
+func NewAPIRegistry(ctx context.Context, targets []config.SealedSecrets) (*Registry, error) {
+	var ts []*Target
+	for _, ss := range targets {
+		sealer, err := NewAPISealer(ctx, ss)
+		if err != nil {
+			return nil, fmt.Errorf("target '%s': %w", ss.Name, err)
+		}
+		ts = append(ts, &Target{Name: ss.Name, Config: ss, Sealer: sealer})
+	}
+	return NewRegistry(ts...), nil
+}
+
+// Default returns the sealer of the default target.
+func (r *Registry) Default() Sealer {
+	if r == nil || len(r.targets) == 0 {
+		return nil
+	}
+	return r.targets[0].Sealer
+}
+
+// Get returns the target with the given name.
+func (r *Registry) Get(name string) (*Target, bool) {
+	if r == nil {
+		return nil, false
+	}
+	t, ok := r.byName[name]
+	return t, ok
+}
+
+// Targets returns all targets in the configured order.
+func (r *Registry) Targets() []*Target {
+	if r == nil {
+		return nil
+	}
+	return r.targets
+}
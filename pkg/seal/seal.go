@@ -4,58 +4,99 @@ import (
 	"bytes"
 	"context"
 	"crypto/rsa"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
 	"github.com/bitnami/sealed-secrets/pkg/kubeseal"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/bakito/sealed-secrets-web/pkg/config"
+	"github.com/bakito/sealed-secrets-web/pkg/metrics"
 )
 
 type Sealer interface {
 	Raw(data Raw) ([]byte, error)
 	Certificate(ctx context.Context) ([]byte, error)
-	Seal(outputFormat string, secret io.Reader) ([]byte, error)
+	Seal(outputFormat string, scope v1alpha1.SealingScope, secret io.Reader) ([]byte, error)
+	Merge(outputFormat string, sealedSecret *v1alpha1.SealedSecret, secret *corev1.Secret) ([]byte, error)
 	Validate(ctx context.Context, secret io.Reader) error
+	ReEncrypt(ctx context.Context, outputFormat string, sealedSecret *v1alpha1.SealedSecret, keys PrivateKeys) ([]byte, error)
+	Fingerprint() string
+	KnownCertificates(ctx context.Context) ([]KnownCertificate, error)
 }
 
 var _ Sealer = &apiSealer{}
 
 func NewAPISealer(ctx context.Context, ss config.SealedSecrets) (Sealer, error) {
-	log.Printf("Connection to sealed secrets with (%s)\n", ss.String())
+	log.Printf("Connection to sealed secrets target '%s' with (%s)\n", ss.Name, ss.String())
 
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	loadingRules.DefaultClientConfig = &clientcmd.DefaultClientConfig
-	cc := clientcmd.NewInteractiveDeferredLoadingClientConfig(loadingRules, nil, os.Stdout)
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: ss.Context}
+	cc := clientcmd.NewInteractiveDeferredLoadingClientConfig(loadingRules, overrides, os.Stdout)
 
-	f, err := kubeseal.OpenCert(ctx, cc, ss.Namespace, ss.Service, ss.CertURL)
+	a := &apiSealer{
+		clientConfig: cc,
+		ss:           ss,
+	}
+
+	data, err := a.readCertificate(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = f.Close() }()
-	pubKey, err := kubeseal.ParseKey(f)
-	if err != nil {
+	if err := a.updateKey(data); err != nil {
 		return nil, err
 	}
 
-	return &apiSealer{
-		clientConfig: cc,
-		ss:           ss,
-		pubKey:       pubKey,
-	}, nil
+	if ss.CertFile != "" {
+		go a.watchCertFile(ctx, certFileWatchInterval)
+	} else if ss.RefreshInterval() > 0 {
+		go a.refreshCertificate(ctx, ss.RefreshInterval())
+	}
+	return a, nil
 }
 
 type apiSealer struct {
 	clientConfig clientcmd.ClientConfig
 	ss           config.SealedSecrets
+	keyLock      sync.RWMutex
 	pubKey       *rsa.PublicKey
+	fingerprint  string
 }
 
+// Certificate fetches the current certificate and swaps in its key, if the controller rotated it.
 func (a *apiSealer) Certificate(ctx context.Context) ([]byte, error) {
+	data, err := a.readCertificate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.updateKey(data); err != nil {
+		log.Printf("Could not update the sealing key: %v\n", err)
+	}
+	return data, nil
+}
+
+func (a *apiSealer) readCertificate(ctx context.Context) ([]byte, error) {
+	start := time.Now()
+	data, err := a.openCertificate(ctx)
+	metrics.ObserveCertificateFetch(a.ss.Name, time.Since(start), err)
+	return data, err
+}
+
+func (a *apiSealer) openCertificate(ctx context.Context) ([]byte, error) {
+	if a.ss.CertFile != "" {
+		return os.ReadFile(a.ss.CertFile)
+	}
 	f, err := kubeseal.OpenCert(ctx, a.clientConfig, a.ss.Namespace, a.ss.Service, a.ss.CertURL)
 	if err != nil {
 		return nil, err
@@ -69,7 +110,7 @@ func (a *apiSealer) Certificate(ctx context.Context) ([]byte, error) {
 	return data, nil
 }
 
-func (a *apiSealer) Seal(outputFormat string, secret io.Reader) ([]byte, error) {
+func (a *apiSealer) Seal(outputFormat string, scope v1alpha1.SealingScope, secret io.Reader) ([]byte, error) {
 	var buf bytes.Buffer
 	if err := kubeseal.Seal(
 		a.clientConfig,
@@ -77,8 +118,8 @@ func (a *apiSealer) Seal(outputFormat string, secret io.Reader) ([]byte, error)
 		secret,
 		&buf,
 		scheme.Codecs,
-		a.pubKey,
-		v1alpha1.DefaultScope,
+		a.publicKey(),
+		scope,
 		false,
 		"",
 		"",
@@ -88,6 +129,35 @@ func (a *apiSealer) Seal(outputFormat string, secret io.Reader) ([]byte, error)
 	return buf.Bytes(), nil
 }
 
+// Merge encrypts the keys of the given secret into the existing sealed secret, analogous to 'kubeseal --merge-into'.
+// The name, namespace and scope of the sealed secret are used, all other encrypted entries are kept as they are.
+func (a *apiSealer) Merge(outputFormat string, sealedSecret *v1alpha1.SealedSecret, secret *corev1.Secret) ([]byte, error) {
+	if sealedSecret.Name == "" {
+		return nil, errors.New("missing metadata.name in SealedSecret")
+	}
+	data := secretData(secret)
+	if len(data) == 0 {
+		return nil, errors.New("secret contains no data to merge")
+	}
+
+	scope := sealedSecret.Scope()
+	pubKey := a.publicKey()
+	if sealedSecret.Spec.EncryptedData == nil {
+		sealedSecret.Spec.EncryptedData = map[string]string{}
+	}
+	for key, value := range data {
+		var buf bytes.Buffer
+		if err := kubeseal.EncryptSecretItem(
+			&buf, sealedSecret.Name, sealedSecret.Namespace, value,
+			scope, pubKey); err != nil {
+			return nil, err
+		}
+		sealedSecret.Spec.EncryptedData[key] = buf.String()
+	}
+
+	return encodeSealedSecret(sealedSecret, outputFormat)
+}
+
 func (a *apiSealer) Raw(data Raw) ([]byte, error) {
 	var buf bytes.Buffer
 	scope := v1alpha1.DefaultScope
@@ -96,25 +166,51 @@ func (a *apiSealer) Raw(data Raw) ([]byte, error) {
 	}
 	if err := kubeseal.EncryptSecretItem(
 		&buf, data.Name, data.Namespace, []byte(data.Value),
-		scope, a.pubKey); err != nil {
+		scope, a.publicKey()); err != nil {
 		return nil, err
 	}
 	return buf.Bytes(), nil
 }
 
-func (a *apiSealer) Validate(ctx context.Context, secret io.Reader) error {
-	return kubeseal.ValidateSealedSecret(
-		ctx,
-		a.clientConfig,
-		a.ss.Namespace,
-		a.ss.Service,
-		secret,
-	)
-}
-
 type Raw struct {
 	Value     string `json:"value"`
 	Name      string `json:"name"`
 	Namespace string `json:"namespace"`
 	Scope     string `json:"scope"`
 }
+
+// secretData returns the plain values of the secret, where stringData takes precedence over data.
+func secretData(secret *corev1.Secret) map[string][]byte {
+	data := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+	for key, value := range secret.Data {
+		data[key] = value
+	}
+	for key, value := range secret.StringData {
+		data[key] = []byte(value)
+	}
+	return data
+}
+
+// encodeSealedSecret encodes a SealedSecret object into the specified format (JSON or YAML).
+func encodeSealedSecret(sealedSecret *v1alpha1.SealedSecret, outputFormat string) ([]byte, error) {
+	var contentType string
+	switch strings.ToLower(outputFormat) {
+	case "json", "":
+		contentType = runtime.ContentTypeJSON
+	case "yaml":
+		contentType = runtime.ContentTypeYAML
+	default:
+		return nil, fmt.Errorf("unsupported output format: %s", outputFormat)
+	}
+
+	info, ok := runtime.SerializerInfoForMediaType(scheme.Codecs.SupportedMediaTypes(), contentType)
+	if !ok {
+		return nil, fmt.Errorf("unsupported output format: %s", outputFormat)
+	}
+	prettyEncoder := info.PrettySerializer
+	if prettyEncoder == nil {
+		prettyEncoder = info.Serializer
+	}
+	encoder := scheme.Codecs.EncoderForVersion(prettyEncoder, v1alpha1.SchemeGroupVersion)
+	return runtime.Encode(encoder, sealedSecret)
+}
@@ -0,0 +1,272 @@
+package seal
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/bitnami/sealed-secrets/pkg/crypto"
+	"github.com/bitnami/sealed-secrets/pkg/kubeseal"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	certUtil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	testCertFile = "../../testdata/cert.pem"
+	testKeyFile  = "../../testdata/key.pem"
+)
+
+var _ = Describe("Seal", func() {
+	var (
+		sealer   *apiSealer
+		privKeys map[string]*rsa.PrivateKey
+	)
+	BeforeEach(func() {
+		f, err := os.Open(testCertFile)
+		Ω(err).ShouldNot(HaveOccurred())
+		defer func() { _ = f.Close() }()
+		pubKey, err := kubeseal.ParseKey(f)
+		Ω(err).ShouldNot(HaveOccurred())
+		sealer = &apiSealer{pubKey: pubKey}
+
+		b, err := os.ReadFile(testKeyFile)
+		Ω(err).ShouldNot(HaveOccurred())
+		key, err := keyutil.ParsePrivateKeyPEM(b)
+		Ω(err).ShouldNot(HaveOccurred())
+		privKey := key.(*rsa.PrivateKey)
+		fp, err := crypto.PublicKeyFingerprint(&privKey.PublicKey)
+		Ω(err).ShouldNot(HaveOccurred())
+		privKeys = map[string]*rsa.PrivateKey{fp: privKey}
+	})
+
+	decrypt := func(value string, label []byte) string {
+		ciphertext, err := base64.StdEncoding.DecodeString(value)
+		Ω(err).ShouldNot(HaveOccurred())
+		plain, err := crypto.HybridDecrypt(rand.Reader, privKeys, ciphertext, label)
+		Ω(err).ShouldNot(HaveOccurred())
+		return string(plain)
+	}
+
+	Context("Merge", func() {
+		var sealedSecret *v1alpha1.SealedSecret
+		BeforeEach(func() {
+			sealedSecret = &v1alpha1.SealedSecret{
+				ObjectMeta: metav1.ObjectMeta{Name: "mysecretname", Namespace: "mysecretnamespace"},
+				Spec: v1alpha1.SealedSecretSpec{
+					EncryptedData: map[string]string{
+						"keep":    "AgBkeepTheValue==",
+						"replace": "AgBreplaceTheValue==",
+					},
+				},
+			}
+		})
+
+		It("should add and replace keys and keep all other entries", func() {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"},
+				StringData: map[string]string{"replace": "new", "add": "added"},
+			}
+
+			out, err := sealer.Merge("json", sealedSecret, secret)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			var result v1alpha1.SealedSecret
+			Ω(runtime.DecodeInto(scheme.Codecs.UniversalDecoder(), out, &result)).Should(Succeed())
+			Ω(result.Name).Should(Equal("mysecretname"))
+			Ω(result.Namespace).Should(Equal("mysecretnamespace"))
+			Ω(result.Spec.EncryptedData).Should(HaveLen(3))
+			Ω(result.Spec.EncryptedData).Should(HaveKeyWithValue("keep", "AgBkeepTheValue=="))
+
+			label := v1alpha1.EncryptionLabel("mysecretnamespace", "mysecretname", v1alpha1.StrictScope)
+			Ω(decrypt(result.Spec.EncryptedData["replace"], label)).Should(Equal("new"))
+			Ω(decrypt(result.Spec.EncryptedData["add"], label)).Should(Equal("added"))
+		})
+
+		It("should use the scope of the sealed secret", func() {
+			sealedSecret.Spec.Template.Annotations = map[string]string{
+				v1alpha1.SealedSecretClusterWideAnnotation: "true",
+			}
+			secret := &corev1.Secret{Data: map[string][]byte{"add": []byte("added")}}
+
+			out, err := sealer.Merge("yaml", sealedSecret, secret)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			var result v1alpha1.SealedSecret
+			Ω(runtime.DecodeInto(scheme.Codecs.UniversalDecoder(), out, &result)).Should(Succeed())
+			label := v1alpha1.EncryptionLabel("", "", v1alpha1.ClusterWideScope)
+			Ω(decrypt(result.Spec.EncryptedData["add"], label)).Should(Equal("added"))
+		})
+
+		It("should fail if the secret has no data", func() {
+			_, err := sealer.Merge("json", sealedSecret, &corev1.Secret{})
+			Ω(err).Should(MatchError("secret contains no data to merge"))
+		})
+
+		It("should fail if the sealed secret has no name", func() {
+			sealedSecret.Name = ""
+			_, err := sealer.Merge("json", sealedSecret, &corev1.Secret{})
+			Ω(err).Should(MatchError("missing metadata.name in SealedSecret"))
+		})
+	})
+
+	Context("Certificate", func() {
+		var (
+			certFile string
+			testCert []byte
+		)
+		BeforeEach(func() {
+			var err error
+			testCert, err = os.ReadFile(testCertFile)
+			Ω(err).ShouldNot(HaveOccurred())
+			certFile = filepath.Join(GinkgoT().TempDir(), "cert.pem")
+			Ω(os.WriteFile(certFile, testCert, 0o600)).Should(Succeed())
+		})
+
+		It("should load the certificate on startup", func() {
+			s, err := NewAPISealer(context.Background(), config.SealedSecrets{CertURL: certFile})
+			Ω(err).ShouldNot(HaveOccurred())
+			as := s.(*apiSealer)
+
+			fp, err := crypto.PublicKeyFingerprint(as.publicKey())
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(as.fingerprint).Should(Equal(fp))
+			Ω(privKeys).Should(HaveKey(fp))
+		})
+
+		It("should swap in a rotated key", func() {
+			s, err := NewAPISealer(context.Background(), config.SealedSecrets{CertURL: certFile})
+			Ω(err).ShouldNot(HaveOccurred())
+			as := s.(*apiSealer)
+			oldFingerprint := as.fingerprint
+
+			newCert := generateCertificate()
+			Ω(os.WriteFile(certFile, newCert, 0o600)).Should(Succeed())
+
+			data, err := as.Certificate(context.Background())
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(data).Should(Equal(newCert))
+			Ω(as.fingerprint).ShouldNot(Equal(oldFingerprint))
+
+			info, err := ParseCertificateInfo(newCert)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(as.fingerprint).Should(Equal(info.Fingerprint))
+		})
+
+		It("should refresh the key periodically", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			s, err := NewAPISealer(ctx, config.SealedSecrets{CertURL: certFile, CertRefreshInterval: new(10 * time.Millisecond)})
+			Ω(err).ShouldNot(HaveOccurred())
+			as := s.(*apiSealer)
+
+			newCert := generateCertificate()
+			info, err := ParseCertificateInfo(newCert)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(os.WriteFile(certFile, newCert, 0o600)).Should(Succeed())
+
+			Eventually(func() string {
+				as.keyLock.RLock()
+				defer as.keyLock.RUnlock()
+				return as.fingerprint
+			}).Should(Equal(info.Fingerprint))
+		})
+
+		It("should load the certificate from a file", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			s, err := NewAPISealer(ctx, config.SealedSecrets{CertFile: certFile})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			data, err := s.Certificate(context.Background())
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(data).Should(Equal(testCert))
+		})
+
+		It("should reload the certificate file on changes", func() {
+			certFileWatchInterval = 10 * time.Millisecond
+			DeferCleanup(func() { certFileWatchInterval = 5 * time.Second })
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			s, err := NewAPISealer(ctx, config.SealedSecrets{CertFile: certFile})
+			Ω(err).ShouldNot(HaveOccurred())
+			as := s.(*apiSealer)
+
+			newCert := generateCertificate()
+			info, err := ParseCertificateInfo(newCert)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(os.WriteFile(certFile, newCert, 0o600)).Should(Succeed())
+
+			Eventually(func() string {
+				as.keyLock.RLock()
+				defer as.keyLock.RUnlock()
+				return as.fingerprint
+			}).Should(Equal(info.Fingerprint))
+		})
+
+		It("should fail if the certificate file does not exist", func() {
+			_, err := NewAPISealer(
+				context.Background(),
+				config.SealedSecrets{CertFile: filepath.Join(GinkgoT().TempDir(), "missing.pem")},
+			)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("should create a sealer for each target", func() {
+			r, err := NewAPIRegistry(context.Background(), []config.SealedSecrets{
+				{Name: "dev", CertURL: certFile},
+				{Name: "prod", CertURL: testCertFile},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(r.Targets()).Should(HaveLen(2))
+			Ω(r.Default()).Should(BeIdenticalTo(r.Targets()[0].Sealer))
+
+			t, ok := r.Get("prod")
+			Ω(ok).Should(BeTrue())
+			Ω(t.Config.CertURL).Should(Equal(testCertFile))
+			_, ok = r.Get("foo")
+			Ω(ok).Should(BeFalse())
+		})
+
+		It("should fail if a target can not be setup", func() {
+			_, err := NewAPIRegistry(context.Background(), []config.SealedSecrets{
+				{Name: "dev", CertURL: filepath.Join(GinkgoT().TempDir(), "missing.pem")},
+			})
+			Ω(err).Should(MatchError(ContainSubstring("target 'dev'")))
+		})
+
+		It("should parse the certificate info", func() {
+			info, err := ParseCertificateInfo(testCert)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(privKeys).Should(HaveKey(info.Fingerprint))
+			Ω(info.NotBefore).Should(Equal(time.Date(2021, 8, 23, 18, 53, 59, 0, time.UTC)))
+			Ω(info.NotAfter).Should(Equal(time.Date(2031, 8, 21, 18, 53, 59, 0, time.UTC)))
+		})
+
+		It("should fail to parse an invalid certificate", func() {
+			_, err := ParseCertificateInfo([]byte("foo"))
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+})
+
+func generateCertificate() []byte {
+	_, cert, err := crypto.GeneratePrivateKeyAndCert(2048, time.Hour, "test")
+	Ω(err).ShouldNot(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: certUtil.CertificateBlockType, Bytes: cert.Raw})
+}
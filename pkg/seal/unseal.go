@@ -0,0 +1,74 @@
+package seal
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/bitnami/sealed-secrets/pkg/crypto"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/keyutil"
+)
+
+// PrivateKeys are the private keys of the sealed secrets controller by the fingerprint of their public key.
+type PrivateKeys map[string]*rsa.PrivateKey
+
+// Add parses the PEM encoded RSA private key and adds it.
+func (k PrivateKeys) Add(data []byte) error {
+	key, err := keyutil.ParsePrivateKeyPEM(data)
+	if err != nil {
+		return err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return fmt.Errorf("unexpected private key type %T", key)
+	}
+	fingerprint, err := crypto.PublicKeyFingerprint(&rsaKey.PublicKey)
+	if err != nil {
+		return err
+	}
+	k[fingerprint] = rsaKey
+	return nil
+}
+
+// ReadPrivateKeys reads the PEM encoded private keys of all files in the directory. Hidden files (e.g. the
+// ..data link of a mounted secret) are ignored, and files that contain no RSA private key are skipped.
+func ReadPrivateKeys(dir string) (PrivateKeys, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	keys := PrivateKeys{}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		file := filepath.Join(dir, e.Name())
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := keys.Add(data); err != nil {
+			log.Printf("Skipping private key file %s: %v\n", file, err)
+		}
+	}
+	return keys, nil
+}
+
+// Unseal decrypts the sealed secret as the controller would. The label of each value is derived from the name,
+// namespace and scope of the sealed secret, so a sealed secret can not be decrypted into another name or namespace.
+func Unseal(sealedSecret *v1alpha1.SealedSecret, keys PrivateKeys) (*corev1.Secret, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no private keys to unseal with")
+	}
+	return sealedSecret.Unseal(scheme.Codecs, keys)
+}
@@ -0,0 +1,92 @@
+package seal
+
+import (
+	"crypto/rsa"
+	"os"
+	"path/filepath"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/bitnami/sealed-secrets/pkg/kubeseal"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Unseal", func() {
+	var (
+		pubKey *rsa.PublicKey
+		keyDir string
+	)
+	BeforeEach(func() {
+		f, err := os.Open(testCertFile)
+		Ω(err).ShouldNot(HaveOccurred())
+		defer func() { _ = f.Close() }()
+		pubKey, err = kubeseal.ParseKey(f)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		keyDir = GinkgoT().TempDir()
+		key, err := os.ReadFile(testKeyFile)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(os.WriteFile(filepath.Join(keyDir, "key.pem"), key, 0o600)).Should(Succeed())
+		Ω(os.WriteFile(filepath.Join(keyDir, "README"), []byte("no key"), 0o600)).Should(Succeed())
+		Ω(os.WriteFile(filepath.Join(keyDir, ".hidden"), key, 0o600)).Should(Succeed())
+	})
+
+	sealed := func(scope v1alpha1.SealingScope) *v1alpha1.SealedSecret {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "mynamespace"},
+			Data:       map[string][]byte{"password": []byte("s3cret")},
+		}
+		if scope != v1alpha1.StrictScope {
+			secret.Annotations = v1alpha1.UpdateScopeAnnotations(nil, scope)
+		}
+		ss, err := v1alpha1.NewSealedSecret(scheme.Codecs, pubKey, secret)
+		Ω(err).ShouldNot(HaveOccurred())
+		return ss
+	}
+
+	It("should read the private keys of the directory", func() {
+		keys, err := ReadPrivateKeys(keyDir)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(keys).Should(HaveLen(1))
+	})
+
+	DescribeTable("should unseal each scope",
+		func(scope v1alpha1.SealingScope) {
+			keys, err := ReadPrivateKeys(keyDir)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			secret, err := Unseal(sealed(scope), keys)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(secret.Name).Should(Equal("mysecret"))
+			Ω(secret.Data).Should(HaveKeyWithValue("password", []byte("s3cret")))
+		},
+		Entry("strict", v1alpha1.StrictScope),
+		Entry("namespace-wide", v1alpha1.NamespaceWideScope),
+		Entry("cluster-wide", v1alpha1.ClusterWideScope),
+	)
+
+	It("should not unseal a strict secret into another namespace", func() {
+		keys, err := ReadPrivateKeys(keyDir)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		ss := sealed(v1alpha1.StrictScope)
+		ss.Namespace = "other"
+		_, err = Unseal(ss, keys)
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("should fail without keys", func() {
+		_, err := Unseal(sealed(v1alpha1.StrictScope), PrivateKeys{})
+		Ω(err).Should(MatchError("no private keys to unseal with"))
+	})
+
+	It("should reject a key that is not a private key", func() {
+		cert, err := os.ReadFile(testCertFile)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(PrivateKeys{}.Add(cert)).ShouldNot(Succeed())
+	})
+})
@@ -0,0 +1,165 @@
+package seal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	"github.com/bitnami/sealed-secrets/pkg/kubeseal"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Validate checks if the sealed secrets can be decrypted by the controller. If the controller is not reachable via
+// its service, the structure of the sealed secrets is checked and, with a verify URL, they are decrypted by the
+// controller over http.
+func (a *apiSealer) Validate(ctx context.Context, secret io.Reader) error {
+	if a.ss.CanValidate() {
+		return kubeseal.ValidateSealedSecret(
+			ctx,
+			a.clientConfig,
+			a.ss.Namespace,
+			a.ss.Service,
+			secret,
+		)
+	}
+
+	sealedSecrets, err := readSealedSecrets(secret)
+	if err != nil {
+		return err
+	}
+	certs, err := a.KnownCertificates(ctx)
+	if err != nil {
+		return err
+	}
+	for _, sealedSecret := range sealedSecrets {
+		if err := CheckStructure(sealedSecret, certs); err != nil {
+			return err
+		}
+	}
+	if a.ss.VerifyURL == "" {
+		return nil
+	}
+	for _, sealedSecret := range sealedSecrets {
+		if err := verify(ctx, a.ss.VerifyURL, sealedSecret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readSealedSecrets reads the sealed secrets of a JSON or (multi document) YAML manifest.
+func readSealedSecrets(r io.Reader) ([]*v1alpha1.SealedSecret, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	var sealedSecrets []*v1alpha1.SealedSecret
+	for {
+		var doc json.RawMessage
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("invalid manifest: %w", err)
+		}
+		if len(doc) == 0 || string(doc) == "null" {
+			continue
+		}
+		var tm metav1.TypeMeta
+		if err := json.Unmarshal(doc, &tm); err != nil {
+			return nil, fmt.Errorf("invalid manifest: %w", err)
+		}
+		if tm.APIVersion != v1alpha1.SchemeGroupVersion.String() || tm.Kind != "SealedSecret" {
+			return nil, fmt.Errorf(
+				"expected kind SealedSecret with apiVersion %s, got kind %q with apiVersion %q",
+				v1alpha1.SchemeGroupVersion, tm.Kind, tm.APIVersion,
+			)
+		}
+		sealedSecret := &v1alpha1.SealedSecret{}
+		if err := json.Unmarshal(doc, sealedSecret); err != nil {
+			return nil, fmt.Errorf("invalid sealed secret: %w", err)
+		}
+		sealedSecrets = append(sealedSecrets, sealedSecret)
+	}
+	if len(sealedSecrets) == 0 {
+		return nil, errors.New("no sealed secret found")
+	}
+	return sealedSecrets, nil
+}
+
+// CheckStructure checks the sealed secret without decrypting it: the scope annotations must match the name and
+// namespace, and each value must be a complete ciphertext of a key size of the known certificates.
+func CheckStructure(sealedSecret *v1alpha1.SealedSecret, certs []KnownCertificate) error {
+	var problems []string
+
+	annotations := sealedSecret.Annotations
+	if annotations[v1alpha1.SealedSecretClusterWideAnnotation] == "true" &&
+		annotations[v1alpha1.SealedSecretNamespaceWideAnnotation] == "true" {
+		problems = append(problems, "both the cluster-wide and the namespace-wide annotation are set")
+	}
+	scope := sealedSecret.Scope()
+	if scope != v1alpha1.ClusterWideScope && sealedSecret.Namespace == "" {
+		problems = append(problems, fmt.Sprintf("%s scope requires a namespace", scope.String()))
+	}
+	if scope == v1alpha1.StrictScope && sealedSecret.Name == "" {
+		problems = append(problems, fmt.Sprintf("%s scope requires a name", scope.String()))
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(sealedSecret.Spec.EncryptedData)) {
+		rsaCiphertext, err := splitCiphertext(sealedSecret.Spec.EncryptedData[key])
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		if len(certs) > 0 && !slices.ContainsFunc(certs, func(c KnownCertificate) bool {
+			return c.pubKey.Size() == len(rsaCiphertext)
+		}) {
+			problems = append(problems, fmt.Sprintf(
+				"%s: encrypted with a %d bit key, no known certificate has this size", key, len(rsaCiphertext)*8))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid sealed secret %s: %s", sealedSecret.Name, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// verifyTimeout limits the time to wait for the verify endpoint of the controller.
+const verifyTimeout = 10 * time.Second
+
+var verifyClient = &http.Client{Timeout: verifyTimeout}
+
+// verify lets the controller decrypt the sealed secret via its verify endpoint.
+func verify(ctx context.Context, url string, sealedSecret *v1alpha1.SealedSecret) error {
+	content, err := json.Marshal(sealedSecret)
+	if err != nil {
+		return fmt.Errorf("error while marshalling sealed secret: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := verifyClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot validate sealed secret: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusConflict:
+		return fmt.Errorf("unable to decrypt sealed secret: %s", sealedSecret.Name)
+	default:
+		return fmt.Errorf("cannot validate sealed secret: %s", resp.Status)
+	}
+}
@@ -0,0 +1,151 @@
+package seal
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	"github.com/bitnami/sealed-secrets/pkg/apis/sealedsecrets/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/bakito/sealed-secrets-web/pkg/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Validate", func() {
+	var (
+		sealer     *apiSealer
+		testSealed *v1alpha1.SealedSecret
+	)
+	BeforeEach(func() {
+		sealer = &apiSealer{ss: config.SealedSecrets{CertFile: testCertFile}}
+		data, err := os.ReadFile(testCertFile)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(sealer.updateKey(data)).Should(Succeed())
+
+		testSealed, err = v1alpha1.NewSealedSecret(scheme.Codecs, sealer.publicKey(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "mynamespace"},
+			Data:       map[string][]byte{"password": []byte("s3cret")},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	manifest := func(ss *v1alpha1.SealedSecret) *bytes.Reader {
+		data, err := encodeSealedSecret(ss, "yaml")
+		Ω(err).ShouldNot(HaveOccurred())
+		return bytes.NewReader(data)
+	}
+
+	Context("without the controller service", func() {
+		It("should accept a valid sealed secret", func() {
+			Ω(sealer.Validate(context.TODO(), manifest(testSealed))).Should(Succeed())
+		})
+
+		It("should report a truncated value", func() {
+			testSealed.Spec.EncryptedData["password"] = testSealed.Spec.EncryptedData["password"][:100]
+			Ω(sealer.Validate(context.TODO(), manifest(testSealed))).Should(MatchError(
+				"invalid sealed secret mysecret: password: truncated: 75 bytes, expected at least 274"))
+		})
+
+		It("should report a missing namespace", func() {
+			testSealed.Namespace = ""
+			Ω(sealer.Validate(context.TODO(), manifest(testSealed))).Should(MatchError(
+				"invalid sealed secret mysecret: strict scope requires a namespace"))
+		})
+
+		It("should reject other kinds", func() {
+			err := sealer.Validate(context.TODO(), bytes.NewReader([]byte("apiVersion: v1\nkind: Secret\n")))
+			Ω(err).Should(MatchError(
+				`expected kind SealedSecret with apiVersion bitnami.com/v1alpha1, got kind "Secret" with apiVersion "v1"`))
+		})
+
+		It("should reject an empty manifest", func() {
+			Ω(sealer.Validate(context.TODO(), bytes.NewReader(nil))).Should(MatchError("no sealed secret found"))
+		})
+	})
+
+	Context("with a verify URL", func() {
+		var status int
+		BeforeEach(func() {
+			status = http.StatusOK
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Ω(r.Method).Should(Equal(http.MethodPost))
+				Ω(r.URL.Path).Should(Equal("/v1/verify"))
+				w.WriteHeader(status)
+			}))
+			DeferCleanup(server.Close)
+			sealer.ss.VerifyURL = server.URL + "/v1/verify"
+		})
+
+		It("should accept a sealed secret the controller can decrypt", func() {
+			Ω(sealer.Validate(context.TODO(), manifest(testSealed))).Should(Succeed())
+		})
+
+		It("should report a sealed secret the controller can not decrypt", func() {
+			status = http.StatusConflict
+			Ω(sealer.Validate(context.TODO(), manifest(testSealed))).Should(
+				MatchError("unable to decrypt sealed secret: mysecret"))
+		})
+
+		It("should report an error of the controller", func() {
+			status = http.StatusInternalServerError
+			Ω(sealer.Validate(context.TODO(), manifest(testSealed))).Should(
+				MatchError("cannot validate sealed secret: 500 Internal Server Error"))
+		})
+
+		It("should not wait longer than the timeout for the controller", func() {
+			done := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				<-done
+			}))
+			DeferCleanup(server.Close)
+			DeferCleanup(func() { close(done) })
+			timeout := verifyClient.Timeout
+			verifyClient.Timeout = 50 * time.Millisecond
+			DeferCleanup(func() { verifyClient.Timeout = timeout })
+			sealer.ss.VerifyURL = server.URL + "/v1/verify"
+
+			Ω(sealer.Validate(context.TODO(), manifest(testSealed))).Should(
+				MatchError(ContainSubstring("Client.Timeout exceeded")))
+		})
+	})
+})
+
+var _ = Describe("CheckStructure", func() {
+	It("should report conflicting scope annotations", func() {
+		ss := &v1alpha1.SealedSecret{ObjectMeta: metav1.ObjectMeta{
+			Name:      "mysecret",
+			Namespace: "mynamespace",
+			Annotations: map[string]string{
+				v1alpha1.SealedSecretClusterWideAnnotation:   "true",
+				v1alpha1.SealedSecretNamespaceWideAnnotation: "true",
+			},
+		}}
+		Ω(CheckStructure(ss, nil)).Should(MatchError(
+			"invalid sealed secret mysecret: both the cluster-wide and the namespace-wide annotation are set"))
+	})
+
+	It("should report a value encrypted with an unknown key size", func() {
+		ss := &v1alpha1.SealedSecret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: "mynamespace"},
+			Spec: v1alpha1.SealedSecretSpec{EncryptedData: v1alpha1.SealedSecretEncryptedData{
+				"password": base64.StdEncoding.EncodeToString(append([]byte{1, 0}, make([]byte, 256+gcmTagSize)...)),
+			}},
+		}
+		key, err := rsa.GenerateKey(rand.Reader, 1024)
+		Ω(err).ShouldNot(HaveOccurred())
+		certs := []KnownCertificate{{pubKey: &key.PublicKey}}
+		Ω(CheckStructure(ss, certs)).Should(MatchError(
+			"invalid sealed secret mysecret: password: encrypted with a 2048 bit key, no known certificate has this size"))
+	})
+})